@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+func newCatCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "cat [flags] [files...]",
+		Short: "concatenate and pretty-print files",
+		Long: `cat decodes each file and prints them one after another as a single stream.
+
+Documents are separated with "---" for YAML and a blank line for every other format.`,
+		DisableFlagsInUseLine: true,
+		RunE:                  runCatCmdFunc,
+	}
+}
+
+func runCatCmdFunc(cmd *cobra.Command, args []string) error {
+	inOpts, err := newInputOptions(cmd)
+	if err != nil {
+		return err
+	}
+	outOpts := newOutputOptions(cmd)
+
+	paths, ok := pathArgs(args)
+	if !ok {
+		return fmt.Errorf("not enough arguments provided")
+	}
+	if len(args) == 0 {
+		outOpts.color = false
+	}
+
+	first := true
+	for _, path := range paths {
+		fileJv, decoder, err := decodeFile(os.ExpandEnv(path), inOpts)
+		if err != nil {
+			return err
+		}
+
+		if fileJv == nil {
+			continue
+		}
+
+		encoder, err := outOpts.encoder(decoder)
+		if err != nil {
+			return err
+		}
+
+		output, err := outOpts.encode(fileJv, encoder)
+		if err != nil {
+			return err
+		}
+
+		if !first {
+			if separator, ok := outOpts.separator(encoder); ok {
+				fmt.Println(separator)
+			} else {
+				fmt.Println()
+			}
+		}
+		first = false
+
+		fmt.Println(string(output))
+	}
+
+	return nil
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jzelinskie/faq/formats"
+	"github.com/jzelinskie/faq/jq"
+)
+
+// commentedYAML is a YAML input file kept alongside its Jv so that the
+// comments of the values the jq program leaves untouched can be carried over
+// to its results.
+type commentedYAML struct {
+	doc *yaml.Node
+
+	// paths holds the path within the document of each result, or is nil if
+	// the program isn't a path expression, in which case every result is
+	// compared against the whole document.
+	paths []*jq.Jv
+}
+
+// isYAML reports whether encoding is the YAML encoding.
+func isYAML(encoding formats.Encoding) bool {
+	return encoding == formats.ByName["yaml"]
+}
+
+// newCommentedYAML parses the comments of yamlBytes and the path of each
+// result of program when run against fileJv.
+//
+// Does not consume fileJv.
+func newCommentedYAML(yamlBytes []byte, program string, fileJv *jq.Jv) (*commentedYAML, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(yamlBytes, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse comments: %s", err)
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	return &commentedYAML{doc: &doc, paths: resultPaths(program, fileJv)}, nil
+}
+
+// resultPaths returns the path of each result of program when run against
+// jv, or nil if program isn't a path expression.
+//
+// Does not consume jv.
+func resultPaths(program string, jv *jq.Jv) []*jq.Jv {
+	libjq, err := jq.New()
+	if err != nil {
+		return nil
+	}
+	defer libjq.Close()
+
+	for _, err := range libjq.Compile("path("+program+")", jq.JvArray()) {
+		if err != nil {
+			return nil
+		}
+	}
+
+	paths, err := libjq.Execute(jv.Copy())
+	if err != nil {
+		return nil
+	}
+	return paths
+}
+
+// free frees the paths of the results.
+func (c *commentedYAML) free() {
+	for _, path := range c.paths {
+		path.Free()
+	}
+}
+
+// encode encodes the i-th of n results as YAML, with the comments of the
+// values it shares with the original document.
+//
+// Consumes jv.
+func (c *commentedYAML) encode(jv *jq.Jv, i, n int) ([]byte, error) {
+	doc := &yaml.Node{Kind: yaml.DocumentNode}
+	original := c.doc.Content[0]
+	if len(c.paths) == n {
+		original = lookupYAMLNode(original, c.paths[i])
+	}
+	if original == c.doc.Content[0] {
+		doc.HeadComment = c.doc.HeadComment
+		doc.FootComment = c.doc.FootComment
+	}
+
+	node := jvToYAMLNode(jv)
+	jv.Free()
+	copyYAMLComments(node, original)
+	doc.Content = []*yaml.Node{node}
+
+	var b bytes.Buffer
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return nil, fmt.Errorf("failed to encode jq program output as yaml: %s", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode jq program output as yaml: %s", err)
+	}
+	return b.Bytes(), nil
+}
+
+// lookupYAMLNode returns the node at path within node, or nil if there is
+// none.
+//
+// Does not consume path.
+func lookupYAMLNode(node *yaml.Node, path *jq.Jv) *yaml.Node {
+	length := path.Copy().ArrayLength()
+	for i := 0; i < length && node != nil; i++ {
+		if node.Kind == yaml.AliasNode {
+			node = node.Alias
+		}
+
+		component := path.Copy().ArrayGet(i)
+		switch component.Kind() {
+		case jq.JvKindString:
+			key, _ := component.StringValue()
+			node = yamlMappingValue(node, key)
+		case jq.JvKindNumber:
+			index, _ := component.ToFloat64()
+			if node.Kind != yaml.SequenceNode || index < 0 || int(index) >= len(node.Content) {
+				node = nil
+			} else {
+				node = node.Content[int(index)]
+			}
+		default:
+			node = nil
+		}
+		component.Free()
+	}
+	return node
+}
+
+// yamlMappingValue returns the value of key in the mapping node, or nil if
+// node isn't a mapping or doesn't have key.
+func yamlMappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// jvToYAMLNode converts jv into a YAML node, keeping the order of the keys of
+// objects.
+//
+// Does not consume jv.
+func jvToYAMLNode(jv *jq.Jv) *yaml.Node {
+	switch jv.Kind() {
+	case jq.JvKindNull:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+	case jq.JvKindTrue:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "true"}
+	case jq.JvKindFalse:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "false"}
+	case jq.JvKindNumber:
+		value := jv.Copy().Dump(jq.JvPrintNone)
+		if strings.ContainsAny(value, ".eE") {
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: value}
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: value}
+	case jq.JvKindString:
+		value, _ := jv.StringValue()
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+	case jq.JvKindArray:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		length := jv.Copy().ArrayLength()
+		for i := 0; i < length; i++ {
+			elem := jv.Copy().ArrayGet(i)
+			node.Content = append(node.Content, jvToYAMLNode(elem))
+			elem.Free()
+		}
+		return node
+	default:
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		jv.ObjectForEach(func(key string, value *jq.Jv) error {
+			node.Content = append(node.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+				jvToYAMLNode(value))
+			return nil
+		})
+		return node
+	}
+}
+
+// copyYAMLComments copies the comments and style of original onto node and
+// its descendants wherever they hold the same kind of value. Scalars only
+// keep their comments if their value is unchanged, and the keys of mappings
+// are put back in their original order, followed by any new keys.
+func copyYAMLComments(node, original *yaml.Node) {
+	if original == nil {
+		return
+	}
+	if original.Kind == yaml.AliasNode {
+		original = original.Alias
+	}
+	if original == nil || node.Kind != original.Kind {
+		return
+	}
+
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Value != original.Value || node.Tag != original.ShortTag() {
+			return
+		}
+	case yaml.SequenceNode:
+		for i := 0; i < len(node.Content) && i < len(original.Content); i++ {
+			copyYAMLComments(node.Content[i], original.Content[i])
+		}
+	case yaml.MappingNode:
+		copyYAMLMappingComments(node, original)
+	}
+
+	node.Style = original.Style
+	node.HeadComment = original.HeadComment
+	node.LineComment = original.LineComment
+	node.FootComment = original.FootComment
+}
+
+// copyYAMLMappingComments copies the comments of the keys and values of the
+// original mapping onto node.
+func copyYAMLMappingComments(node, original *yaml.Node) {
+	originalIndex := make(map[string]int)
+	for i := 0; i+1 < len(original.Content); i += 2 {
+		originalIndex[original.Content[i].Value] = i
+	}
+
+	var kept, added []*yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		j, ok := originalIndex[key.Value]
+		if !ok {
+			added = append(added, key, value)
+			continue
+		}
+
+		originalKey := original.Content[j]
+		key.HeadComment = originalKey.HeadComment
+		key.LineComment = originalKey.LineComment
+		key.FootComment = originalKey.FootComment
+		copyYAMLComments(value, original.Content[j+1])
+		kept = append(kept, key, value)
+	}
+
+	// Sort the kept pairs by their position in the original mapping.
+	for i := 2; i < len(kept); i += 2 {
+		for j := i; j > 0 && originalIndex[kept[j].Value] < originalIndex[kept[j-2].Value]; j -= 2 {
+			kept[j], kept[j-2] = kept[j-2], kept[j]
+			kept[j+1], kept[j-1] = kept[j-1], kept[j+1]
+		}
+	}
+
+	node.Content = append(kept, added...)
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/jzelinskie/faq/formats"
+	"github.com/jzelinskie/faq/jq"
+)
+
+func newCompactCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "compact [flags] [files...]",
+		Short: "minify files in their own format",
+		Long: `compact prints each file in the most compact representation of its format,
+followed by a summary of the bytes saved on stderr unless --quiet is given.
+
+JSON is printed without any whitespace, YAML in flow style and TOML without blank
+lines or indentation outside of multi-line strings. With --in-place, the files are rewritten instead.`,
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.MinimumNArgs(1),
+		RunE:                  runCompactCmdFunc,
+	}
+
+	cmd.Flags().BoolP("in-place", "i", false, "write the compacted files back instead of printing them")
+	cmd.Flags().BoolP("quiet", "q", false, "don't print the summary of the bytes saved")
+
+	return cmd
+}
+
+func runCompactCmdFunc(cmd *cobra.Command, args []string) error {
+	inOpts, err := newInputOptions(cmd)
+	if err != nil {
+		return err
+	}
+	inPlace, _ := cmd.Flags().GetBool("in-place")
+	quiet, _ := cmd.Flags().GetBool("quiet")
+	errorOutput, _ := cmd.Flags().GetString("error-output")
+
+	// The errors of each file are printed as they happen.
+	cmd.SilenceUsage = true
+
+	failed := 0
+	for _, path := range args {
+		path = os.ExpandEnv(path)
+		if err := compactFile(path, inOpts, inPlace, quiet); err != nil {
+			if _, ok := err.(*fileError); !ok {
+				err = &fileError{path: path, err: err}
+			}
+			printError(err, errorOutput)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to compact %d of %d files", failed, len(args))
+	}
+	return nil
+}
+
+// compactFile compacts the file at path, printing the result or writing it
+// back to the file, and reports the bytes saved on stderr unless quiet is set.
+func compactFile(path string, inOpts inputOptions, inPlace, quiet bool) error {
+	fileJv, decoder, fileBytes, err := decodeFileBytes(path, inOpts)
+	if err != nil {
+		return err
+	}
+	if fileJv == nil {
+		return fmt.Errorf("file at %s is empty", path)
+	}
+
+	var output []byte
+	switch {
+	case isYAML(decoder):
+		output, err = compactYAML(fileJv)
+	case decoder == formats.ByName["toml"]:
+		// TOML is compacted as written, because converting it to JSON and back
+		// loses its comments.
+		fileJv.Free()
+		output = compactTOML(fileBytes)
+	default:
+		output, err = decoder.UnmarshalJSONBytes([]byte(fileJv.Dump(jq.JvPrintNone)))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to compact file at %s: %s", path, err)
+	}
+	if !bytes.HasSuffix(output, []byte("\n")) {
+		output = append(output, '\n')
+	}
+
+	if inPlace {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(path, output, info.Mode()); err != nil {
+			return err
+		}
+	} else {
+		os.Stdout.Write(output)
+	}
+
+	if !quiet {
+		fmt.Fprintf(os.Stderr, "%s: %d -> %d bytes (saved %d)\n", path, len(fileBytes), len(output), len(fileBytes)-len(output))
+	}
+	return nil
+}
+
+// compactYAML encodes jv as a YAML document in flow style.
+//
+// Consumes jv.
+func compactYAML(jv *jq.Jv) ([]byte, error) {
+	node := jvToYAMLNode(jv)
+	jv.Free()
+	node.Style = yaml.FlowStyle
+
+	var b bytes.Buffer
+	encoder := yaml.NewEncoder(&b)
+	if err := encoder.Encode(node); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// compactTOML removes the blank lines and indentation of TOML, leaving the
+// contents of multi-line strings as they are.
+func compactTOML(tomlBytes []byte) []byte {
+	var b bytes.Buffer
+	inString := false
+	for _, line := range strings.Split(string(tomlBytes), "\n") {
+		startsInString := inString
+		if (strings.Count(line, `"""`)+strings.Count(line, "'''"))%2 == 1 {
+			inString = !inString
+		}
+
+		if !startsInString {
+			if line = strings.TrimSpace(line); line == "" {
+				continue
+			}
+		}
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n"))
+}
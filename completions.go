@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jzelinskie/faq/formats"
+)
+
+func newCompletionsCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "completions [bash|zsh|fish|powershell]",
+		Short: "print a shell completion script",
+		Long: `completions prints a script that completes faq's flags, format names and files
+for the given shell.
+
+To load completions in the current bash session:
+
+  source <(faq completions bash)
+
+To load them for every zsh session, write the script into a directory in your $fpath:
+
+  faq completions zsh > "${fpath[1]}/_faq"`,
+		DisableFlagsInUseLine: true,
+		ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+		Args:                  cobra.ExactValidArgs(1),
+		RunE:                  runCompletionsCmdFunc,
+	}
+}
+
+func runCompletionsCmdFunc(cmd *cobra.Command, args []string) error {
+	root := cmd.Root()
+	switch args[0] {
+	case "bash":
+		return root.GenBashCompletion(os.Stdout)
+	case "zsh":
+		return root.GenZshCompletion(os.Stdout)
+	case "fish":
+		return root.GenFishCompletion(os.Stdout, true)
+	case "powershell":
+		return root.GenPowerShellCompletionWithDesc(os.Stdout)
+	default:
+		return fmt.Errorf("unsupported shell %s", args[0])
+	}
+}
+
+// completeFormats completes the values of --input-format and --output-format
+// with the names of the supported formats.
+func completeFormats(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var names []string
+	for name := range formats.ByName {
+		if strings.HasPrefix(name, toComplete) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if strings.HasPrefix("auto", toComplete) {
+		names = append([]string{"auto"}, names...)
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
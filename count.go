@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jzelinskie/faq/formats"
+	"github.com/jzelinskie/faq/jq"
+)
+
+func newCountCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "count [flags] [filter string] [files...]",
+		Short: "count the results of a jq program",
+		Long: `count prints the total number of results a jq program produces across all of the
+files, such as the number of active users with '.[] | select(.status == "active")'.
+
+With --format json, an object is printed instead, holding the total and the count
+for each file, e.g. {"count": 3, "files": {"users.json": 3}}.`,
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.MinimumNArgs(1),
+		RunE:                  runCountCmdFunc,
+	}
+
+	cmd.Flags().String("format", "text", "format of the count (text, json)")
+
+	return cmd
+}
+
+func runCountCmdFunc(cmd *cobra.Command, args []string) error {
+	inOpts, err := newInputOptions(cmd)
+	if err != nil {
+		return err
+	}
+	outOpts := newOutputOptions(cmd)
+
+	format, _ := cmd.Flags().GetString("format")
+	if format != "text" && format != "json" {
+		return fmt.Errorf("unsupported --format %s", format)
+	}
+
+	program := args[0]
+	paths, ok := pathArgs(args[1:])
+	if !ok {
+		return fmt.Errorf("not enough arguments provided")
+	}
+	if len(args) == 1 {
+		outOpts.color = false
+	}
+
+	total := 0
+	counts := jq.JvObject()
+	for _, path := range paths {
+		path = os.ExpandEnv(path)
+		n, err := countResults(program, path, inOpts)
+		if err != nil {
+			counts.Free()
+			return err
+		}
+
+		label := path
+		if path == "/dev/stdin" {
+			label = "<stdin>"
+		}
+		total += n
+		counts = counts.ObjectSet(jq.JvFromString(label), jq.JvFromFloat(float64(n)))
+	}
+
+	if format == "text" {
+		counts.Free()
+		fmt.Println(total)
+		return nil
+	}
+
+	result := jq.JvObject().
+		ObjectSet(jq.JvFromString("count"), jq.JvFromFloat(float64(total))).
+		ObjectSet(jq.JvFromString("files"), counts)
+	output, err := outOpts.encode(result, formats.ByName["json"])
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(output))
+
+	return nil
+}
+
+// countResults returns the number of results program produces for the file at
+// path. An empty file produces no results.
+func countResults(program, path string, inOpts inputOptions) (int, error) {
+	fileJv, _, err := decodeFile(path, inOpts)
+	if err != nil {
+		return 0, err
+	}
+	if fileJv == nil {
+		return 0, nil
+	}
+
+	libjq, err := jq.New()
+	if err != nil {
+		fileJv.Free()
+		return 0, fmt.Errorf("failed to initialize libjq: %s", err)
+	}
+	defer libjq.Close()
+
+	for _, err := range libjq.Compile(program, jq.JvArray()) {
+		if err != nil {
+			fileJv.Free()
+			return 0, &fileError{path: path, err: fmt.Errorf("failed to compile jq program for file at %s: %s", path, err)}
+		}
+	}
+
+	resultJvs, err := libjq.Execute(fileJv)
+	if err != nil {
+		return 0, &fileError{path: path, err: fmt.Errorf("failed to execute jq program for file at %s: %s", path, err)}
+	}
+	for _, resultJv := range resultJvs {
+		resultJv.Free()
+	}
+	return len(resultJvs), nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jzelinskie/faq/jq"
+)
+
+func newEditCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "edit [flags] [file]",
+		Short: "edit a file as JSON in your editor",
+		Long: `edit opens a file in $EDITOR as JSON and, once the editor exits, writes the
+edited document back to the file in its original format.
+
+Nothing is written if the document is left unchanged. If the edited JSON is
+invalid, you are asked whether to re-open the editor or discard the changes.`,
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.ExactArgs(1),
+		RunE:                  runEditCmdFunc,
+	}
+}
+
+func runEditCmdFunc(cmd *cobra.Command, args []string) error {
+	inOpts, err := newInputOptions(cmd)
+	if err != nil {
+		return err
+	}
+	outOpts := newOutputOptions(cmd)
+	outOpts.raw, outOpts.color = false, false
+
+	path := os.ExpandEnv(args[0])
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	fileJv, decoder, fileBytes, err := decodeFileBytes(path, inOpts)
+	if err != nil {
+		return err
+	}
+	if fileJv == nil {
+		return fmt.Errorf("file at %s is empty", path)
+	}
+
+	encoder, err := outOpts.fileEncoder(decoder, fileBytes)
+	if err != nil {
+		fileJv.Free()
+		return err
+	}
+
+	original := []byte(fileJv.Dump(jq.JvPrintPretty|jq.JvPrintSpace1) + "\n")
+
+	tmpfile, err := ioutil.TempFile("", "faq-edit-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpfile.Name())
+
+	_, err = tmpfile.Write(original)
+	if closeErr := tmpfile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write temporary file: %s", err)
+	}
+
+	stdin := bufio.NewReader(os.Stdin)
+	for {
+		if err := runEditor(tmpfile.Name()); err != nil {
+			return err
+		}
+
+		edited, err := ioutil.ReadFile(tmpfile.Name())
+		if err != nil {
+			return fmt.Errorf("failed to read edited file: %s", err)
+		}
+		if sha256.Sum256(edited) == sha256.Sum256(original) {
+			fmt.Fprintln(os.Stderr, "Edit cancelled, no changes made.")
+			return nil
+		}
+
+		editedJv, err := jq.JvFromJSONBytes(bytes.TrimSpace(edited))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Edited file is not valid JSON: %s\n", err)
+			if !confirm(stdin, "Re-open the editor?") {
+				return errors.New("edit discarded")
+			}
+			continue
+		}
+
+		output, err := outOpts.encode(editedJv, encoder)
+		if err != nil {
+			return err
+		}
+		if !strings.HasSuffix(string(output), "\n") {
+			output = append(output, '\n')
+		}
+		return ioutil.WriteFile(path, output, info.Mode())
+	}
+}
+
+// runEditor opens path in the editor named by $EDITOR, falling back to vi, and
+// waits for it to exit.
+func runEditor(path string) error {
+	editor := strings.Fields(os.Getenv("EDITOR"))
+	if len(editor) == 0 {
+		editor = []string{"vi"}
+	}
+
+	cmd := exec.Command(editor[0], append(editor[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to run editor %s: %s", editor[0], err)
+	}
+	return nil
+}
+
+// confirm asks a yes or no question on stderr, defaulting to yes.
+func confirm(r *bufio.Reader, question string) bool {
+	fmt.Fprintf(os.Stderr, "%s [Y/n] ", question)
+	answer, err := r.ReadString('\n')
+	if err != nil && answer == "" {
+		return false
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "", "y", "yes":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jzelinskie/faq/formats"
+	"github.com/jzelinskie/faq/jq"
+)
+
+func newEnvCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "env [flags] [filter string]",
+		Short: "run a jq program against the environment variables",
+		Long: `env runs a jq program against an object mapping the name of each environment
+variable to its value, such as '.PATH', without reading any files.
+
+The program defaults to '.', so 'faq env -o yaml' prints every environment variable
+as YAML.`,
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.MaximumNArgs(1),
+		RunE:                  runEnvCmdFunc,
+	}
+}
+
+func runEnvCmdFunc(cmd *cobra.Command, args []string) error {
+	outOpts := newOutputOptions(cmd)
+
+	program := "."
+	if len(args) == 1 {
+		program = args[0]
+	}
+
+	encoder, err := outOpts.encoder(formats.ByName["json"])
+	if err != nil {
+		return err
+	}
+
+	libjq, err := jq.New()
+	if err != nil {
+		return fmt.Errorf("failed to initialize libjq: %s", err)
+	}
+	defer libjq.Close()
+
+	for _, err := range libjq.Compile(program, jq.JvArray()) {
+		if err != nil {
+			return fmt.Errorf("failed to compile jq program: %s", err)
+		}
+	}
+
+	resultJvs, err := libjq.Execute(jq.JvFromEnv())
+	if err != nil {
+		return fmt.Errorf("failed to execute jq program: %s", err)
+	}
+
+	first := true
+	for _, resultJv := range resultJvs {
+		if outOpts.omit(resultJv) {
+			resultJv.Free()
+			continue
+		}
+
+		output, err := outOpts.encode(resultJv, encoder)
+		if err != nil {
+			return err
+		}
+		if separator, ok := outOpts.separator(encoder); ok && !first {
+			fmt.Println(separator)
+		}
+		first = false
+
+		fmt.Println(string(output))
+	}
+
+	return nil
+}
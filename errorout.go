@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+
+	"github.com/jzelinskie/faq/formats"
+	"github.com/jzelinskie/faq/jq"
+)
+
+// fileError is an error that occurred while processing the file at path,
+// along with the position in the file it occurred at, if known.
+type fileError struct {
+	path         string
+	line, column int
+	err          error
+}
+
+func (e *fileError) Error() string { return e.err.Error() }
+func (e *fileError) Unwrap() error { return e.err }
+
+// at sets the position of e to the one reported by cause, if any.
+func (e *fileError) at(cause error) *fileError {
+	if match := positionRegexp.FindStringSubmatch(cause.Error()); match != nil {
+		e.line, _ = strconv.Atoi(match[1])
+		e.column, _ = strconv.Atoi(match[2])
+	}
+	return e
+}
+
+// positionRegexp matches the position in the error messages of the decoders,
+// such as "yaml: line 3: ..." or "(line 2, column 5)".
+var positionRegexp = regexp.MustCompile(`\bline (\d+)(?:,? column (\d+))?`)
+
+// printError prints err to stderr as an object encoded in the named format,
+// such as {"error": "...", "file": "config.yaml", "line": 3, "column": 5}.
+// The file and position are only included when they are known. The "text"
+// format prints err as cobra does.
+func printError(err error, format string) {
+	if format == "text" {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return
+	}
+
+	obj := jq.JvObject().ObjectSet(jq.JvFromString("error"), jq.JvFromString(err.Error()))
+	if ferr, ok := err.(*fileError); ok {
+		obj = obj.ObjectSet(jq.JvFromString("file"), jq.JvFromString(ferr.path))
+		if ferr.line > 0 {
+			obj = obj.ObjectSet(jq.JvFromString("line"), jq.JvFromFloat(float64(ferr.line)))
+		}
+		if ferr.column > 0 {
+			obj = obj.ObjectSet(jq.JvFromString("column"), jq.JvFromFloat(float64(ferr.column)))
+		}
+	}
+
+	output, encodeErr := formats.ByName[format].UnmarshalJSONBytes([]byte(obj.Dump(jq.JvPrintNone)))
+	if encodeErr != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return
+	}
+	fmt.Fprintln(os.Stderr, string(output))
+}
@@ -0,0 +1,64 @@
+package formats
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/alecthomas/chroma/quick"
+	"github.com/joho/godotenv"
+)
+
+type dotenvEncoding struct{}
+
+func (dotenvEncoding) MarshalJSONBytes(dotenvBytes []byte) ([]byte, error) {
+	env, err := godotenv.Unmarshal(string(dotenvBytes))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(env)
+}
+
+func (dotenvEncoding) UnmarshalJSONBytes(jsonBytes []byte) ([]byte, error) {
+	var value interface{}
+	if err := json.Unmarshal(jsonBytes, &value); err != nil {
+		return nil, err
+	}
+
+	// dotenv files can only represent a flat mapping of strings to strings.
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("dotenv output must be an object, got %s", jsonBytes)
+	}
+
+	env := make(map[string]string, len(obj))
+	for key, value := range obj {
+		str, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("dotenv output value for %s must be a string, got %T", key, value)
+		}
+		env[key] = str
+	}
+
+	output, err := godotenv.Marshal(env)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(output), nil
+}
+
+func (dotenvEncoding) Raw(dotenvBytes []byte) ([]byte, error)         { return dotenvBytes, nil }
+func (dotenvEncoding) PrettyPrint(dotenvBytes []byte) ([]byte, error) { return dotenvBytes, nil }
+
+func (dotenvEncoding) Color(dotenvBytes []byte) ([]byte, error) {
+	var b bytes.Buffer
+	if err := quick.Highlight(&b, string(dotenvBytes), "bash", ChromaFormatter(), ChromaStyle()); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func init() {
+	ByName["dotenv"] = dotenvEncoding{}
+	ByName["env"] = dotenvEncoding{}
+}
@@ -0,0 +1,58 @@
+package formats
+
+import "testing"
+
+func TestDotenvMarshal(t *testing.T) {
+	var table = []struct {
+		input  string
+		output string
+	}{
+		{"A=1\nB=two\n", `{"A":"1","B":"two"}`},
+		{"# comment\nexport A=1\n", `{"A":"1"}`},
+		{`A="quoted value"`, `{"A":"quoted value"}`},
+		{"A=\"line1\\nline2\"", `{"A":"line1\nline2"}`},
+		{"A=\"multi\nline\"", `{"A":"multi\nline"}`},
+		{"A='single $quoted'", `{"A":"single $quoted"}`},
+	}
+
+	for _, tt := range table {
+		t.Run("", func(t *testing.T) {
+			outputBytes, err := dotenvEncoding{}.MarshalJSONBytes([]byte(tt.input))
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+			if string(outputBytes) != tt.output {
+				t.Errorf("unexpected output: %s instead of %s", outputBytes, tt.output)
+			}
+		})
+	}
+}
+
+func TestDotenvUnmarshal(t *testing.T) {
+	var table = []struct {
+		input  string
+		output string
+		err    bool
+	}{
+		{`{"B":"two","A":"1"}`, "A=1\nB=\"two\"", false},
+		{`{"A":"say \"hi\""}`, `A="say \"hi\""`, false},
+		{`{"A":1}`, "", true},
+		{`{"A":{"B":"C"}}`, "", true},
+		{`["A"]`, "", true},
+		{`"A"`, "", true},
+		{`1`, "", true},
+		{`null`, "", true},
+	}
+
+	for _, tt := range table {
+		t.Run("", func(t *testing.T) {
+			outputBytes, err := dotenvEncoding{}.UnmarshalJSONBytes([]byte(tt.input))
+			if (err != nil) != tt.err {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if string(outputBytes) != tt.output {
+				t.Errorf("unexpected output: %s instead of %s", outputBytes, tt.output)
+			}
+		})
+	}
+}
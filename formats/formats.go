@@ -9,6 +9,20 @@ type Encoding interface {
 	Color([]byte) ([]byte, error)
 }
 
+// MultiDocumentEncoding is implemented by formats that have their own syntax
+// for separating multiple documents within a single stream.
+type MultiDocumentEncoding interface {
+	Encoding
+	DocumentSeparator() string
+}
+
+// ConfigurableEncoding is implemented by formats whose decoding can be tuned
+// with format-specific options.
+type ConfigurableEncoding interface {
+	Encoding
+	MarshalJSONBytesWithOptions([]byte, map[string]string) ([]byte, error)
+}
+
 // ByName is a mapping from dynamically registered encoding names to Encoding
 // implementations.
 var ByName = map[string]Encoding{}
@@ -0,0 +1,168 @@
+package formats
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	// Register the well-known types so that descriptor sets built without
+	// --include_imports can still be resolved.
+	_ "google.golang.org/protobuf/types/known/anypb"
+	_ "google.golang.org/protobuf/types/known/durationpb"
+	_ "google.golang.org/protobuf/types/known/emptypb"
+	_ "google.golang.org/protobuf/types/known/fieldmaskpb"
+	_ "google.golang.org/protobuf/types/known/structpb"
+	_ "google.golang.org/protobuf/types/known/timestamppb"
+	_ "google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// protoJSONEncoding decodes the JSON mapping of a protocol buffer message
+// using the message's descriptor, so that the input is validated against the
+// schema and well-known types are normalized into their canonical JSON form.
+//
+// Output is plain JSON, which is already valid protojson.
+type protoJSONEncoding struct{}
+
+func (protoJSONEncoding) MarshalJSONBytes(protoJSONBytes []byte) ([]byte, error) {
+	return nil, errors.New("protojson requires a descriptor option")
+}
+
+// MarshalJSONBytesWithOptions supports the following options:
+//
+//	descriptor: path to a FileDescriptorSet, as written by
+//	            `protoc --descriptor_set_out` (required)
+//	message:    fully-qualified name of the message to decode (defaults to
+//	            the first message of the last file in the set)
+func (protoJSONEncoding) MarshalJSONBytesWithOptions(protoJSONBytes []byte, opts map[string]string) ([]byte, error) {
+	for key := range opts {
+		if key != "descriptor" && key != "message" {
+			return nil, fmt.Errorf("unsupported format option %s", key)
+		}
+	}
+	if opts["descriptor"] == "" {
+		return nil, errors.New("protojson requires a descriptor option")
+	}
+
+	md, types, err := loadProtoMessage(opts["descriptor"], opts["message"])
+	if err != nil {
+		return nil, err
+	}
+
+	msg := dynamicpb.NewMessage(md)
+	if err := (protojson.UnmarshalOptions{Resolver: types}).Unmarshal(protoJSONBytes, msg); err != nil {
+		return nil, err
+	}
+	return protojson.MarshalOptions{Resolver: types}.Marshal(msg)
+}
+
+func (protoJSONEncoding) UnmarshalJSONBytes(jsonBytes []byte) ([]byte, error) {
+	return jsonEncoding{}.UnmarshalJSONBytes(jsonBytes)
+}
+
+func (protoJSONEncoding) Raw(jsonBytes []byte) ([]byte, error) {
+	return jsonEncoding{}.Raw(jsonBytes)
+}
+
+func (protoJSONEncoding) PrettyPrint(jsonBytes []byte) ([]byte, error) {
+	return jsonEncoding{}.PrettyPrint(jsonBytes)
+}
+
+func (protoJSONEncoding) Color(jsonBytes []byte) ([]byte, error) {
+	return jsonEncoding{}.Color(jsonBytes)
+}
+
+// loadProtoMessage reads the FileDescriptorSet at path and returns the
+// descriptor of the named message, along with a registry of every message in
+// the set for resolving google.protobuf.Any values.
+func loadProtoMessage(path, name string) (protoreflect.MessageDescriptor, *protoregistry.Types, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(b, &set); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse protobuf descriptor %s: %s", path, err)
+	}
+	if len(set.File) == 0 {
+		return nil, nil, fmt.Errorf("protobuf descriptor %s contains no files", path)
+	}
+
+	files, err := protodesc.NewFiles(withWellKnownTypes(&set))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load protobuf descriptor %s: %s", path, err)
+	}
+
+	types := new(protoregistry.Types)
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		registerMessages(types, fd.Messages())
+		return true
+	})
+
+	if name == "" {
+		last := set.File[len(set.File)-1]
+		if len(last.MessageType) == 0 {
+			return nil, nil, fmt.Errorf("protobuf descriptor %s has no messages in %s", path, last.GetName())
+		}
+		name = last.MessageType[0].GetName()
+		if pkg := last.GetPackage(); pkg != "" {
+			name = pkg + "." + name
+		}
+	}
+
+	mt, err := types.FindMessageByName(protoreflect.FullName(name))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find message %s in protobuf descriptor %s", name, path)
+	}
+	return mt.Descriptor(), types, nil
+}
+
+// withWellKnownTypes returns set with the descriptors of any well-known types
+// that it imports but does not contain added to it.
+func withWellKnownTypes(set *descriptorpb.FileDescriptorSet) *descriptorpb.FileDescriptorSet {
+	included := make(map[string]bool, len(set.File))
+	for _, file := range set.File {
+		included[file.GetName()] = true
+	}
+
+	var missing []*descriptorpb.FileDescriptorProto
+	for _, file := range set.File {
+		for _, dep := range file.Dependency {
+			if included[dep] {
+				continue
+			}
+			if fd, err := protoregistry.GlobalFiles.FindFileByPath(dep); err == nil {
+				missing = append(missing, protodesc.ToFileDescriptorProto(fd))
+				included[dep] = true
+			}
+		}
+	}
+
+	if len(missing) == 0 {
+		return set
+	}
+	return &descriptorpb.FileDescriptorSet{File: append(missing, set.File...)}
+}
+
+func registerMessages(types *protoregistry.Types, messages protoreflect.MessageDescriptors) {
+	for i := 0; i < messages.Len(); i++ {
+		md := messages.Get(i)
+		if md.IsMapEntry() {
+			continue
+		}
+		types.RegisterMessage(dynamicpb.NewMessageType(md))
+		registerMessages(types, md.Messages())
+	}
+}
+
+func init() {
+	ByName["protojson"] = protoJSONEncoding{}
+}
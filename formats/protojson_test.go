@@ -0,0 +1,116 @@
+package formats
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// writeTestDescriptor writes a FileDescriptorSet for the following file,
+// without its imports, and returns its path.
+//
+//	syntax = "proto3";
+//	package test;
+//	import "google/protobuf/timestamp.proto";
+//	message Event {
+//	  string user_name = 1;
+//	  google.protobuf.Timestamp created = 2;
+//	  int64 count = 3;
+//	}
+func writeTestDescriptor(t *testing.T) string {
+	file := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("test/event.proto"),
+		Package:    proto.String("test"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/timestamp.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Event"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				{
+					Name:     proto.String("user_name"),
+					JsonName: proto.String("userName"),
+					Number:   proto.Int32(1),
+					Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+				},
+				{
+					Name:     proto.String("created"),
+					JsonName: proto.String("created"),
+					Number:   proto.Int32(2),
+					Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+					TypeName: proto.String(".google.protobuf.Timestamp"),
+				},
+				{
+					Name:     proto.String("count"),
+					JsonName: proto.String("count"),
+					Number:   proto.Int32(3),
+					Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					Type:     descriptorpb.FieldDescriptorProto_TYPE_INT64.Enum(),
+				},
+			},
+		}},
+	}
+
+	b, err := proto.Marshal(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{file}})
+	if err != nil {
+		t.Fatalf("failed to marshal descriptor: %s", err)
+	}
+
+	f, err := ioutil.TempFile("", "faq-descriptor")
+	if err != nil {
+		t.Fatalf("failed to create descriptor file: %s", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(b); err != nil {
+		t.Fatalf("failed to write descriptor file: %s", err)
+	}
+	return f.Name()
+}
+
+func TestProtoJSONMarshalWithOptions(t *testing.T) {
+	descriptor := writeTestDescriptor(t)
+	defer os.Remove(descriptor)
+
+	var table = []struct {
+		input  string
+		opts   map[string]string
+		output string
+		err    bool
+	}{
+		{`{"userName":"jimmy"}`, map[string]string{"descriptor": descriptor}, `{"userName":"jimmy"}`, false},
+		{`{"user_name":"jimmy"}`, map[string]string{"descriptor": descriptor, "message": "test.Event"}, `{"userName":"jimmy"}`, false},
+		{`{"created":"2018-01-01T00:00:00.000Z","count":5}`, map[string]string{"descriptor": descriptor}, `{"created":"2018-01-01T00:00:00Z","count":"5"}`, false},
+		{`{"created":{"seconds":1}}`, map[string]string{"descriptor": descriptor}, "", true},
+		{`{"unknown":1}`, map[string]string{"descriptor": descriptor}, "", true},
+		{`{}`, map[string]string{"descriptor": descriptor, "message": "test.Missing"}, "", true},
+		{`{}`, map[string]string{"descriptor": descriptor, "unknown": "true"}, "", true},
+		{`{}`, map[string]string{}, "", true},
+	}
+
+	for _, tt := range table {
+		t.Run("", func(t *testing.T) {
+			outputBytes, err := protoJSONEncoding{}.MarshalJSONBytesWithOptions([]byte(tt.input), tt.opts)
+			if (err != nil) != tt.err {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if err != nil {
+				return
+			}
+
+			// protojson deliberately randomizes its whitespace.
+			var compacted bytes.Buffer
+			if err := json.Compact(&compacted, outputBytes); err != nil {
+				t.Fatalf("invalid JSON output %s: %s", outputBytes, err)
+			}
+			if compacted.String() != tt.output {
+				t.Errorf("unexpected output: %s instead of %s", compacted.String(), tt.output)
+			}
+		})
+	}
+}
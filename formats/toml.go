@@ -20,8 +20,46 @@ func (tomlEncoding) MarshalJSONBytes(tomlBytes []byte) ([]byte, error) {
 }
 
 func (tomlEncoding) UnmarshalJSONBytes(jsonBytes []byte) ([]byte, error) {
+	return unmarshalTOML(jsonBytes, nil)
+}
+
+// tomlNumberKindsEncoding is the TOML encoding, except that it keeps the
+// numbers at the paths of floats in original as floats.
+type tomlNumberKindsEncoding struct {
+	tomlEncoding
+	original interface{}
+}
+
+// TOMLWithNumberKinds returns the TOML Encoding for writing back a modified
+// copy of the TOML document original.
+//
+// JSON doesn't distinguish integers from floats, so TOML's UnmarshalJSONBytes
+// writes numbers without a fractional part as integers. The returned Encoding
+// instead writes any number at the same path as a float in original as a
+// float, so that a float such as 1.0 that is left unchanged isn't turned into
+// an integer.
+func TOMLWithNumberKinds(original []byte) (Encoding, error) {
+	var obj interface{}
+	if err := toml.Unmarshal(original, &obj); err != nil {
+		return nil, err
+	}
+	return &tomlNumberKindsEncoding{original: obj}, nil
+}
+
+func (e *tomlNumberKindsEncoding) UnmarshalJSONBytes(jsonBytes []byte) ([]byte, error) {
+	return unmarshalTOML(jsonBytes, e.original)
+}
+
+func unmarshalTOML(jsonBytes []byte, original interface{}) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(jsonBytes))
+	decoder.UseNumber()
+
 	var obj interface{}
-	err := json.Unmarshal(jsonBytes, &obj)
+	if err := decoder.Decode(&obj); err != nil {
+		return nil, err
+	}
+
+	obj, err := tomlNumbers(obj, original)
 	if err != nil {
 		return nil, err
 	}
@@ -33,6 +71,64 @@ func (tomlEncoding) UnmarshalJSONBytes(jsonBytes []byte) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// tomlNumbers replaces the json.Numbers within v with int64s if they are
+// integers and float64s otherwise. Numbers at the path of a float in original,
+// or in an array that has any floats, are always float64s.
+func tomlNumbers(v, original interface{}) (interface{}, error) {
+	var err error
+	switch v := v.(type) {
+	case map[string]interface{}:
+		originalMap, _ := original.(map[string]interface{})
+		for key, value := range v {
+			if v[key], err = tomlNumbers(value, originalMap[key]); err != nil {
+				return nil, err
+			}
+		}
+	case []interface{}:
+		hasFloat := false
+		for i, value := range v {
+			if v[i], err = tomlNumbers(value, tomlIndex(original, i)); err != nil {
+				return nil, err
+			}
+			_, isFloat := v[i].(float64)
+			hasFloat = hasFloat || isFloat
+		}
+
+		// TOML arrays can't mix integers and floats.
+		if hasFloat {
+			for i, value := range v {
+				if n, ok := value.(int64); ok {
+					v[i] = float64(n)
+				}
+			}
+		}
+	case json.Number:
+		if _, isFloat := original.(float64); !isFloat {
+			if i, err := v.Int64(); err == nil {
+				return i, nil
+			}
+		}
+		return v.Float64()
+	}
+	return v, nil
+}
+
+// tomlIndex returns the element i of a decoded TOML array, or nil if original
+// isn't an array or is too short.
+func tomlIndex(original interface{}, i int) interface{} {
+	switch original := original.(type) {
+	case []interface{}:
+		if i < len(original) {
+			return original[i]
+		}
+	case []map[string]interface{}:
+		if i < len(original) {
+			return original[i]
+		}
+	}
+	return nil
+}
+
 func (tomlEncoding) Raw(tomlBytes []byte) ([]byte, error)         { return tomlBytes, nil }
 func (tomlEncoding) PrettyPrint(tomlBytes []byte) ([]byte, error) { return tomlBytes, nil }
 
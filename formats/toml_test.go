@@ -0,0 +1,61 @@
+package formats
+
+import "testing"
+
+func TestTOMLUnmarshal(t *testing.T) {
+	var table = []struct {
+		input  string
+		output string
+	}{
+		{`{"port":8080}`, "port = 8080\n"},
+		{`{"ratio":0.5}`, "ratio = 0.5\n"},
+		{`{"big":1e3}`, "big = 1000.0\n"},
+		{`{"mixed":[1,1.5]}`, "mixed = [1.0, 1.5]\n"},
+		{`{"ports":[80,443]}`, "ports = [80, 443]\n"},
+		{`{"server":{"port":8080}}`, "[server]\n  port = 8080\n"},
+	}
+
+	for _, tt := range table {
+		t.Run("", func(t *testing.T) {
+			outputBytes, err := tomlEncoding{}.UnmarshalJSONBytes([]byte(tt.input))
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+			if string(outputBytes) != tt.output {
+				t.Errorf("unexpected output: %q instead of %q", outputBytes, tt.output)
+			}
+		})
+	}
+}
+
+func TestTOMLWithNumberKinds(t *testing.T) {
+	original := "port = 8080\ntimeout = 1.0\nweights = [1.0, 2.0]\n\n[[servers]]\n  scale = 2.0\n"
+
+	var table = []struct {
+		input  string
+		output string
+	}{
+		{`{"port":8080,"timeout":1}`, "port = 8080\ntimeout = 1.0\n"},
+		{`{"port":9090,"timeout":2}`, "port = 9090\ntimeout = 2.0\n"},
+		{`{"weights":[1,2,3]}`, "weights = [1.0, 2.0, 3.0]\n"},
+		{`{"servers":[{"scale":2}]}`, "[[servers]]\n  scale = 2.0\n"},
+		{`{"added":1}`, "added = 1\n"},
+	}
+
+	encoder, err := TOMLWithNumberKinds([]byte(original))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, tt := range table {
+		t.Run("", func(t *testing.T) {
+			outputBytes, err := encoder.UnmarshalJSONBytes([]byte(tt.input))
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+			if string(outputBytes) != tt.output {
+				t.Errorf("unexpected output: %q instead of %q", outputBytes, tt.output)
+			}
+		})
+	}
+}
@@ -1,6 +1,10 @@
 package formats
 
-import "os"
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
 
 // trueColorSupported returns true if the tty is configured to support
 // truecolor.
@@ -32,3 +36,28 @@ func ChromaStyle() string {
 	}
 	return style
 }
+
+// boolOptions parses opts as booleans, rejecting any key that is not present
+// in defaults and using defaults for keys that are omitted.
+//
+// This function is useful for implementing ConfigurableEncoding.
+func boolOptions(opts map[string]string, defaults map[string]bool) (map[string]bool, error) {
+	parsed := make(map[string]bool, len(defaults))
+	for key, value := range defaults {
+		parsed[key] = value
+	}
+
+	for key, value := range opts {
+		if _, ok := defaults[key]; !ok {
+			return nil, fmt.Errorf("unsupported format option %s", key)
+		}
+
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("format option %s must be a boolean: %s", key, err)
+		}
+		parsed[key] = b
+	}
+
+	return parsed, nil
+}
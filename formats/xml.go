@@ -17,6 +17,23 @@ func (xmlEncoding) MarshalJSONBytes(xmlBytes []byte) ([]byte, error) {
 	return xmap.Json()
 }
 
+// MarshalJSONBytesWithOptions supports the following options:
+//
+//	cast: convert numeric and boolean text into JSON numbers and booleans
+//	      (default true)
+func (xmlEncoding) MarshalJSONBytesWithOptions(xmlBytes []byte, opts map[string]string) ([]byte, error) {
+	parsed, err := boolOptions(opts, map[string]bool{"cast": true})
+	if err != nil {
+		return nil, err
+	}
+
+	xmap, err := mxj.NewMapXml(xmlBytes, parsed["cast"])
+	if err != nil {
+		return nil, err
+	}
+	return xmap.Json()
+}
+
 func (xmlEncoding) UnmarshalJSONBytes(jsonBytes []byte) ([]byte, error) {
 	xmap, err := mxj.NewMapJson(jsonBytes)
 	if err != nil {
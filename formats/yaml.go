@@ -5,6 +5,7 @@ import (
 
 	"github.com/alecthomas/chroma/quick"
 	"github.com/ghodss/yaml"
+	yamlv2 "gopkg.in/yaml.v2"
 )
 
 type yamlEncoding struct{}
@@ -13,6 +14,26 @@ func (yamlEncoding) MarshalJSONBytes(yamlBytes []byte) ([]byte, error) {
 	return yaml.YAMLToJSON(yamlBytes)
 }
 
+// MarshalJSONBytesWithOptions supports the following options:
+//
+//	strict: reject duplicate keys (default false)
+func (yamlEncoding) MarshalJSONBytesWithOptions(yamlBytes []byte, opts map[string]string) ([]byte, error) {
+	parsed, err := boolOptions(opts, map[string]bool{"strict": false})
+	if err != nil {
+		return nil, err
+	}
+
+	if parsed["strict"] {
+		// YAMLToJSON keeps the last of any duplicate keys, so check for them
+		// first with yaml.v2's strict decoding.
+		var v interface{}
+		if err := yamlv2.UnmarshalStrict(yamlBytes, &v); err != nil {
+			return nil, err
+		}
+	}
+	return yaml.YAMLToJSON(yamlBytes)
+}
+
 func (yamlEncoding) UnmarshalJSONBytes(jsonBytes []byte) ([]byte, error) {
 	return yaml.JSONToYAML(jsonBytes)
 }
@@ -20,6 +41,8 @@ func (yamlEncoding) UnmarshalJSONBytes(jsonBytes []byte) ([]byte, error) {
 func (yamlEncoding) Raw(yamlBytes []byte) ([]byte, error)         { return yamlBytes, nil }
 func (yamlEncoding) PrettyPrint(yamlBytes []byte) ([]byte, error) { return yamlBytes, nil }
 
+func (yamlEncoding) DocumentSeparator() string { return "---" }
+
 func (yamlEncoding) Color(yamlBytes []byte) ([]byte, error) {
 	var b bytes.Buffer
 	if err := quick.Highlight(&b, string(yamlBytes), "yaml", ChromaFormatter(), ChromaStyle()); err != nil {
@@ -0,0 +1,30 @@
+package formats
+
+import "testing"
+
+func TestYAMLMarshalWithOptions(t *testing.T) {
+	var table = []struct {
+		input  string
+		opts   map[string]string
+		output string
+		err    bool
+	}{
+		{"a: 1\na: 2\n", map[string]string{}, `{"a":2}`, false},
+		{"a: 1\na: 2\n", map[string]string{"strict": "true"}, "", true},
+		{"a: 1\nb: 2\n", map[string]string{"strict": "true"}, `{"a":1,"b":2}`, false},
+		{"a: 1\n", map[string]string{"strict": "maybe"}, "", true},
+		{"a: 1\n", map[string]string{"unknown": "true"}, "", true},
+	}
+
+	for _, tt := range table {
+		t.Run("", func(t *testing.T) {
+			outputBytes, err := yamlEncoding{}.MarshalJSONBytesWithOptions([]byte(tt.input), tt.opts)
+			if (err != nil) != tt.err {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if string(outputBytes) != tt.output {
+				t.Errorf("unexpected output: %s instead of %s", outputBytes, tt.output)
+			}
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jzelinskie/faq/jq"
+)
+
+func newGenerateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "generate [flags]",
+		Short: "generate a skeleton file from a JSON Schema",
+		Long: `generate prints a file populated with the default values of a JSON Schema, along
+with stub values for the required fields that have no default, such as "" for
+strings and 0 for numbers.
+
+Optional fields are only included when they or their own fields have defaults.
+The output is in the format of the schema unless one is given with -o.`,
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.NoArgs,
+		RunE:                  runGenerateCmdFunc,
+	}
+
+	cmd.Flags().String("schema", "", "JSON Schema to generate the file from")
+
+	return cmd
+}
+
+func runGenerateCmdFunc(cmd *cobra.Command, args []string) error {
+	inOpts, err := newInputOptions(cmd)
+	if err != nil {
+		return err
+	}
+	outOpts := newOutputOptions(cmd)
+
+	schemaPath, _ := cmd.Flags().GetString("schema")
+	if schemaPath == "" {
+		return errors.New("--schema is required")
+	}
+	schemaPath = os.ExpandEnv(schemaPath)
+
+	schemaJv, decoder, err := decodeFile(schemaPath, inOpts)
+	if err != nil {
+		return err
+	}
+	if schemaJv == nil {
+		return fmt.Errorf("schema at %s is empty", schemaPath)
+	}
+	defer schemaJv.Free()
+
+	encoder, err := outOpts.encoder(decoder)
+	if err != nil {
+		return err
+	}
+
+	g := schemaGenerator{root: schemaJv}
+	output, err := outOpts.encode(g.value(schemaJv, true, 0), encoder)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(output))
+
+	return nil
+}
+
+// maxSchemaDepth is the depth at which schemaGenerator stops following
+// nested schemas, so that recursive schemas terminate.
+const maxSchemaDepth = 32
+
+// schemaGenerator builds example values from the schemas within root.
+type schemaGenerator struct {
+	root *jq.Jv
+}
+
+// value returns the value generated from schema, or nil if there is nothing
+// to generate because schema is optional and has no defaults.
+//
+// Does not consume schema.
+func (g schemaGenerator) value(schema *jq.Jv, required bool, depth int) *jq.Jv {
+	if schema.Kind() != jq.JvKindObject || depth > maxSchemaDepth {
+		if required {
+			return jq.JvNull()
+		}
+		return nil
+	}
+
+	if ref := schemaKeyword(schema, "$ref"); ref != nil {
+		target := g.resolve(ref)
+		if target == nil {
+			if required {
+				return jq.JvNull()
+			}
+			return nil
+		}
+		defer target.Free()
+		return g.value(target, required, depth+1)
+	}
+
+	if def := schemaKeyword(schema, "default"); def != nil {
+		return def
+	}
+
+	if required {
+		if c := schemaKeyword(schema, "const"); c != nil {
+			return c
+		}
+		if enum := schemaKeyword(schema, "enum"); enum != nil {
+			if enum.Kind() == jq.JvKindArray && enum.Copy().ArrayLength() > 0 {
+				return enum.ArrayGet(0)
+			}
+			enum.Free()
+		}
+	}
+
+	switch schemaType(schema) {
+	case "object":
+		return g.object(schema, required, depth)
+	case "array":
+		if required {
+			return jq.JvArray()
+		}
+	case "string":
+		if required {
+			return jq.JvFromString("")
+		}
+	case "integer", "number":
+		if required {
+			return jq.JvFromFloat(0)
+		}
+	case "boolean":
+		if required {
+			return jq.JvFromBool(false)
+		}
+	default:
+		if required {
+			return jq.JvNull()
+		}
+	}
+	return nil
+}
+
+// object returns the object generated from an object schema, holding its
+// required properties and those that have defaults.
+//
+// Does not consume schema.
+func (g schemaGenerator) object(schema *jq.Jv, required bool, depth int) *jq.Jv {
+	requiredNames := make(map[string]bool)
+	if names := schemaKeyword(schema, "required"); names != nil {
+		if names.Kind() == jq.JvKindArray {
+			for _, name := range names.ToGoVal().([]interface{}) {
+				if name, ok := name.(string); ok {
+					requiredNames[name] = true
+				}
+			}
+		}
+		names.Free()
+	}
+
+	obj := jq.JvObject()
+	generated := false
+	if props := schemaKeyword(schema, "properties"); props != nil {
+		if props.Kind() == jq.JvKindObject {
+			props.ObjectForEach(func(name string, prop *jq.Jv) error {
+				if value := g.value(prop, requiredNames[name], depth+1); value != nil {
+					obj = obj.ObjectSet(jq.JvFromString(name), value)
+					generated = true
+				}
+				return nil
+			})
+		}
+		props.Free()
+	}
+
+	if !generated && !required {
+		obj.Free()
+		return nil
+	}
+	return obj
+}
+
+// resolve returns the schema referred to by a local $ref such as
+// "#/definitions/port", or nil if it can't be found.
+//
+// Consumes ref.
+func (g schemaGenerator) resolve(ref *jq.Jv) *jq.Jv {
+	str, err := ref.StringValue()
+	ref.Free()
+	if err != nil || !strings.HasPrefix(str, "#") {
+		return nil
+	}
+
+	var tokens []string
+	if ptr := strings.TrimPrefix(str, "#"); ptr != "" {
+		for _, token := range strings.Split(strings.TrimPrefix(ptr, "/"), "/") {
+			tokens = append(tokens, strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1))
+		}
+	}
+
+	target, err := g.root.GetPath(jq.JvFromStringSlice(tokens))
+	if err != nil {
+		return nil
+	}
+	if target.Kind() != jq.JvKindObject {
+		target.Free()
+		return nil
+	}
+	return target
+}
+
+// schemaKeyword returns the value of keyword in an object schema, or nil if
+// it isn't present.
+//
+// Does not consume schema.
+func schemaKeyword(schema *jq.Jv, keyword string) *jq.Jv {
+	var value *jq.Jv
+	schema.ObjectForEach(func(key string, v *jq.Jv) error {
+		if key == keyword {
+			value = v.Copy()
+		}
+		return nil
+	})
+	return value
+}
+
+// schemaType returns the type of values described by an object schema,
+// preferring the first type other than null when there are several. Schemas
+// without a type are objects if they have properties.
+//
+// Does not consume schema.
+func schemaType(schema *jq.Jv) string {
+	t := schemaKeyword(schema, "type")
+	if t == nil {
+		if props := schemaKeyword(schema, "properties"); props != nil {
+			props.Free()
+			return "object"
+		}
+		return ""
+	}
+	defer t.Free()
+
+	switch types := t.ToGoVal().(type) {
+	case string:
+		return types
+	case []interface{}:
+		first := ""
+		for _, elem := range types {
+			if name, ok := elem.(string); ok {
+				if name != "null" {
+					return name
+				}
+				first = name
+			}
+		}
+		return first
+	}
+	return ""
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jzelinskie/faq/jq"
+)
+
+func newGetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get [flags] [path] [file]",
+		Short: "print the value at a path",
+		Long: `get prints the single value at a path such as ".metadata.name" in a file.
+
+Strings are printed without quotes and any other value is printed as compact JSON.
+It is an error for the path to refer to more than one value.`,
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.RangeArgs(1, 2),
+		RunE:                  runGetCmdFunc,
+	}
+}
+
+func runGetCmdFunc(cmd *cobra.Command, args []string) error {
+	inOpts, err := newInputOptions(cmd)
+	if err != nil {
+		return err
+	}
+
+	paths, ok := pathArgs(args[1:])
+	if !ok {
+		return fmt.Errorf("not enough arguments provided")
+	}
+	path := os.ExpandEnv(paths[0])
+
+	fileJv, _, err := decodeFile(path, inOpts)
+	if err != nil {
+		return err
+	}
+	if fileJv == nil {
+		fileJv = jq.JvNull()
+	}
+
+	libjq, err := jq.New()
+	if err != nil {
+		fileJv.Free()
+		return fmt.Errorf("failed to initialize libjq: %s", err)
+	}
+	defer libjq.Close()
+
+	for _, err := range libjq.Compile(args[0], jq.JvArray()) {
+		if err != nil {
+			fileJv.Free()
+			return fmt.Errorf("invalid path %s: %s", args[0], err)
+		}
+	}
+
+	resultJvs, err := libjq.Execute(fileJv)
+	if err != nil {
+		return fmt.Errorf("failed to get %s from file at %s: %s", args[0], path, err)
+	}
+	if len(resultJvs) != 1 {
+		for _, resultJv := range resultJvs {
+			resultJv.Free()
+		}
+		return fmt.Errorf("%s refers to %d values in file at %s, expected 1", args[0], len(resultJvs), path)
+	}
+
+	resultJv := resultJvs[0]
+	if str, err := resultJv.StringValue(); err == nil {
+		resultJv.Free()
+		fmt.Println(str)
+		return nil
+	}
+	fmt.Println(resultJv.Dump(jq.JvPrintNone))
+	return nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jzelinskie/faq/jq"
+)
+
+// hashAlgorithms are the algorithms supported by `faq hash`.
+var hashAlgorithms = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+func newHashCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "hash [flags] [files...]",
+		Short: "print a hash of the contents of files",
+		Long: `hash prints a hash of the document in each file that only depends on its
+contents, so that the same document gives the same hash whatever its format,
+formatting, comments or order of keys.
+
+The document is hashed as canonical JSON, with the keys of every object sorted and
+without any whitespace. When hashing several files, each hash is followed by the
+name of its file.`,
+		DisableFlagsInUseLine: true,
+		RunE:                  runHashCmdFunc,
+	}
+
+	cmd.Flags().String("algorithm", "sha256", "hash algorithm (md5, sha1, sha256, sha512)")
+
+	return cmd
+}
+
+func runHashCmdFunc(cmd *cobra.Command, args []string) error {
+	inOpts, err := newInputOptions(cmd)
+	if err != nil {
+		return err
+	}
+
+	algorithm, _ := cmd.Flags().GetString("algorithm")
+	newHash, ok := hashAlgorithms[strings.ToLower(algorithm)]
+	if !ok {
+		return fmt.Errorf("unsupported --algorithm %s", algorithm)
+	}
+
+	paths, ok := pathArgs(args)
+	if !ok {
+		return fmt.Errorf("not enough arguments provided")
+	}
+
+	for _, path := range paths {
+		path = os.ExpandEnv(path)
+		fileJv, _, err := decodeFile(path, inOpts)
+		if err != nil {
+			return err
+		}
+		if fileJv == nil {
+			fileJv = jq.JvNull()
+		}
+
+		h := newHash()
+		h.Write([]byte(fileJv.Dump(jq.JvPrintSorted)))
+		sum := hex.EncodeToString(h.Sum(nil))
+
+		if len(paths) == 1 {
+			fmt.Println(sum)
+		} else {
+			fmt.Printf("%s  %s\n", sum, path)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2018 Jimmy Zelinskie
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package jq
+
+import (
+	"errors"
+
+	"github.com/btcsuite/btcutil/base58"
+)
+
+// base58CheckVersion is the version byte that EncodeBase58Check prefixes to
+// the JSON text before computing its checksum.
+const base58CheckVersion = 0
+
+// EncodeBase58 returns the compact JSON text of jv encoded as base58, with the
+// Bitcoin alphabet.
+//
+// Returns an error if jv is invalid.
+//
+// Does not consume the invocant.
+func (jv *Jv) EncodeBase58() (string, error) {
+	if !jv.IsValid() {
+		return "", errors.New("EncodeBase58: jv is invalid")
+	}
+	return base58.Encode([]byte(jv.Copy().Dump(JvPrintNone))), nil
+}
+
+// EncodeBase58Check is EncodeBase58, but uses base58check, which prefixes a
+// version byte of 0 and suffixes a 4-byte checksum so that JvFromBase58Check
+// can detect corrupted input.
+//
+// Returns an error if jv is invalid.
+//
+// Does not consume the invocant.
+func (jv *Jv) EncodeBase58Check() (string, error) {
+	if !jv.IsValid() {
+		return "", errors.New("EncodeBase58Check: jv is invalid")
+	}
+	return base58.CheckEncode([]byte(jv.Copy().Dump(JvPrintNone)), base58CheckVersion), nil
+}
+
+// JvFromBase58 returns a new jv parsed from JSON text encoded as base58, as
+// returned by EncodeBase58.
+//
+// Returns an error if s is not base58 or doesn't decode to JSON.
+func JvFromBase58(s string) (*Jv, error) {
+	decoded := base58.Decode(s)
+	if len(decoded) == 0 {
+		return nil, errors.New("JvFromBase58: invalid base58")
+	}
+	return JvFromJSONBytes(decoded)
+}
+
+// JvFromBase58Check returns a new jv parsed from JSON text encoded as
+// base58check, as returned by EncodeBase58Check.
+//
+// Returns base58.ErrChecksum if the checksum doesn't match, or an error if s
+// is otherwise not base58check, wasn't encoded with a version byte of 0, or
+// doesn't decode to JSON.
+func JvFromBase58Check(s string) (*Jv, error) {
+	decoded, version, err := base58.CheckDecode(s)
+	if err != nil {
+		return nil, err
+	}
+	if version != base58CheckVersion {
+		return nil, errors.New("JvFromBase58Check: unexpected version byte")
+	}
+	return JvFromJSONBytes(decoded)
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2018 Jimmy Zelinskie
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package jq_test
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcutil/base58"
+
+	"github.com/jzelinskie/faq/jq"
+)
+
+func TestJvBase58(t *testing.T) {
+	table := []struct {
+		testName string
+		input    string
+	}{
+		{"Null", `null`},
+		{"String", `"faq"`},
+		{"Number", `1.5`},
+		{"Object", `{"name":"faq","tags":["a","b"],"nested":{"ok":true}}`},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			input := mustParse(t, tt.input)
+			defer input.Free()
+
+			encoded, err := input.EncodeBase58()
+			if err != nil {
+				t.Fatalf("EncodeBase58() failed: %s", err)
+			}
+			decoded, err := jq.JvFromBase58(encoded)
+			if err != nil {
+				t.Fatalf("JvFromBase58() failed: %s", err)
+			}
+			if got := decoded.Dump(jq.JvPrintNone); got != tt.input {
+				t.Errorf("JvFromBase58(EncodeBase58()) got: %s, want: %s", got, tt.input)
+			}
+
+			encoded, err = input.EncodeBase58Check()
+			if err != nil {
+				t.Fatalf("EncodeBase58Check() failed: %s", err)
+			}
+			decoded, err = jq.JvFromBase58Check(encoded)
+			if err != nil {
+				t.Fatalf("JvFromBase58Check() failed: %s", err)
+			}
+			if got := decoded.Dump(jq.JvPrintNone); got != tt.input {
+				t.Errorf("JvFromBase58Check(EncodeBase58Check()) got: %s, want: %s", got, tt.input)
+			}
+		})
+	}
+}
+
+func TestJvEncodeBase58(t *testing.T) {
+	input := mustParse(t, `"faq"`)
+	defer input.Free()
+
+	encoded, err := input.EncodeBase58()
+	if err != nil {
+		t.Fatalf("EncodeBase58() failed: %s", err)
+	}
+	if want := base58.Encode([]byte(`"faq"`)); encoded != want {
+		t.Errorf("EncodeBase58() got: %s, want: %s", encoded, want)
+	}
+
+	if _, err := jq.JvInvalid().EncodeBase58(); err == nil {
+		t.Error("EncodeBase58() of an invalid jv unexpectedly succeeded")
+	}
+}
+
+func TestJvFromBase58Errors(t *testing.T) {
+	table := []struct {
+		testName string
+		input    string
+	}{
+		{"Empty", ``},
+		{"InvalidCharacter", `0OIl`},
+		{"NotJSON", base58.Encode([]byte(`{"a":`))},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			if jv, err := jq.JvFromBase58(tt.input); err == nil {
+				t.Errorf("JvFromBase58() got: %s, want an error", jv.Dump(jq.JvPrintNone))
+			}
+		})
+	}
+}
+
+func TestJvFromBase58CheckChecksum(t *testing.T) {
+	input := mustParse(t, `{"name":"faq"}`)
+	defer input.Free()
+
+	encoded, err := input.EncodeBase58Check()
+	if err != nil {
+		t.Fatalf("EncodeBase58Check() failed: %s", err)
+	}
+
+	// Replace one character, changing the payload without changing its
+	// length, which the checksum must catch.
+	corrupted := []byte(encoded)
+	if corrupted[3] == '2' {
+		corrupted[3] = '3'
+	} else {
+		corrupted[3] = '2'
+	}
+	if _, err := jq.JvFromBase58Check(string(corrupted)); err != base58.ErrChecksum {
+		t.Errorf("JvFromBase58Check() of corrupted input got error: %v, want: %v", err, base58.ErrChecksum)
+	}
+
+	if _, err := jq.JvFromBase58Check(base58.CheckEncode([]byte(`{}`), 1)); err == nil {
+		t.Error("JvFromBase58Check() of another version unexpectedly succeeded")
+	}
+	if _, err := jq.JvFromBase58Check("1"); err == nil {
+		t.Error("JvFromBase58Check() of truncated input unexpectedly succeeded")
+	}
+}
@@ -0,0 +1,532 @@
+// Copyright (c) 2018 Jimmy Zelinskie
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package jq
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sync"
+)
+
+// DebugHandler is called by Debug with its message and a copy of the value
+// being debugged, which it must free. The default handler logs them with
+// log.Printf.
+var DebugHandler = func(msg string, jv *Jv) {
+	if msg == "" {
+		log.Printf("DEBUG: %s", jv.Dump(JvPrintNone))
+		return
+	}
+	log.Printf("DEBUG: %s: %s", msg, jv.Dump(JvPrintNone))
+}
+
+// maxCachedPrograms bounds the number of compiled programs kept by
+// programCache, each of which holds its own jq_state.
+const maxCachedPrograms = 128
+
+// cachedProgram is a compiled jq program guarded so that it can be shared
+// between goroutines.
+type cachedProgram struct {
+	sync.Mutex
+	jq      *Jq
+	program string
+
+	// users and evicted are guarded by programCache, so that a program is only
+	// closed once it has been evicted and nothing is running it.
+	users   int
+	evicted bool
+}
+
+// programCache maps the source of a jq program to its compiled form so that
+// the helpers wrapping jq builtins only pay the cost of compilation once. The
+// least recently used programs are evicted once there are more than
+// maxCachedPrograms.
+var programCache = struct {
+	sync.Mutex
+	programs map[string]*list.Element
+	lru      *list.List
+}{programs: make(map[string]*list.Element), lru: list.New()}
+
+// runCached executes program against input, compiling it on first use.
+//
+// Consumes input.
+func runCached(program string, input *Jv) ([]*Jv, error) {
+	p, err := acquireProgram(program)
+	if err != nil {
+		input.Free()
+		return nil, err
+	}
+	defer releaseProgram(p)
+
+	p.Lock()
+	defer p.Unlock()
+
+	results, err := p.jq.Execute(input)
+	if err != nil {
+		freeAll(results)
+		return nil, err
+	}
+	return results, nil
+}
+
+// acquireProgram returns the compiled form of program from programCache,
+// compiling it and evicting the least recently used program if it isn't
+// there. It must be released with releaseProgram.
+func acquireProgram(program string) (*cachedProgram, error) {
+	programCache.Lock()
+	defer programCache.Unlock()
+
+	if elem, ok := programCache.programs[program]; ok {
+		programCache.lru.MoveToFront(elem)
+		p := elem.Value.(*cachedProgram)
+		p.users++
+		return p, nil
+	}
+
+	libjq, err := New()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize libjq: %s", err)
+	}
+
+	if errs := libjq.Compile(program, JvArray()); len(errs) > 0 {
+		libjq.Close()
+		return nil, errs[0]
+	}
+
+	p := &cachedProgram{jq: libjq, program: program, users: 1}
+	programCache.programs[program] = programCache.lru.PushFront(p)
+
+	if programCache.lru.Len() > maxCachedPrograms {
+		oldest := programCache.lru.Remove(programCache.lru.Back()).(*cachedProgram)
+		delete(programCache.programs, oldest.program)
+		oldest.evicted = true
+		if oldest.users == 0 {
+			oldest.jq.Close()
+		}
+	}
+	return p, nil
+}
+
+// releaseProgram releases a program returned by acquireProgram, closing it if
+// it has since been evicted and nothing else is running it.
+func releaseProgram(p *cachedProgram) {
+	programCache.Lock()
+	defer programCache.Unlock()
+
+	p.users--
+	if p.evicted && p.users == 0 {
+		p.jq.Close()
+	}
+}
+
+// runCachedOne is like runCached, but for programs that must produce exactly
+// one result.
+//
+// Consumes input.
+func runCachedOne(program string, input *Jv) (*Jv, error) {
+	results, err := runCached(program, input)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(results) != 1 {
+		freeAll(results)
+		return nil, fmt.Errorf("jq program `%s` produced %d results, expected 1", program, len(results))
+	}
+	return results[0], nil
+}
+
+// runCachedCollect runs a program of the form `[...]` and returns the
+// elements of the array it produces.
+//
+// Consumes input.
+func runCachedCollect(program string, input *Jv) ([]*Jv, error) {
+	result, err := runCachedOne(program, input)
+	if err != nil {
+		return nil, err
+	}
+
+	len := result.Copy().ArrayLength()
+	elems := make([]*Jv, len)
+	for i := 0; i < len; i++ {
+		elems[i] = result.Copy().ArrayGet(i)
+	}
+	result.Free()
+	return elems, nil
+}
+
+// runCachedBool runs a program that must produce a single boolean.
+//
+// Consumes input.
+func runCachedBool(program string, input *Jv) (bool, error) {
+	result, err := runCachedOne(program, input)
+	if err != nil {
+		return false, err
+	}
+	defer result.Free()
+
+	switch result.Kind() {
+	case JvKindTrue:
+		return true, nil
+	case JvKindFalse:
+		return false, nil
+	default:
+		return false, fmt.Errorf("jq program `%s` produced %s, expected boolean", program, result.Kind())
+	}
+}
+
+func freeAll(jvs []*Jv) {
+	for _, jv := range jvs {
+		jv.Free()
+	}
+}
+
+// Reduce runs jq's `reduce .[] as $x (init; filter)` against jv. The current
+// element is available to filter as `$x`.
+//
+// Compiled programs are cached, keyed on filter. init is passed in alongside
+// jv rather than written into the program, so that each initial value doesn't
+// compile a program of its own.
+//
+// Consumes init, but not the invocant.
+func (jv *Jv) Reduce(init *Jv, filter string) (*Jv, error) {
+	program := fmt.Sprintf("reduce .[1][] as $x (.[0]; %s)", filter)
+	return runCachedOne(program, JvArray().ArrayAppend(init).ArrayAppend(jv.Copy()))
+}
+
+var identifierRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Label runs jq's `label $label | filter` against jv and returns every result
+// produced before filter evaluates `break $label`, which Break builds. For
+// example, the first element of an array:
+//
+//	brk, _ := jq.Break("out")
+//	jv.Label("out", ".[] | ., "+brk)
+//
+// label must be a bare identifier without the leading "$".
+//
+// Does not consume the invocant.
+func (jv *Jv) Label(label, filter string) ([]*Jv, error) {
+	if err := checkLabel(label); err != nil {
+		return nil, err
+	}
+	program := fmt.Sprintf("label $%s | %s", label, filter)
+	return runCached(program, jv.Copy())
+}
+
+// Break returns jq's `break $label` expression, which stops a Label with the
+// same label from producing any more results.
+//
+// jq only allows a break lexically inside the body of its label, so Break
+// returns the expression to be written into the filter passed to Label rather
+// than evaluating anything itself.
+//
+// label must be a bare identifier without the leading "$".
+func Break(label string) (string, error) {
+	if err := checkLabel(label); err != nil {
+		return "", err
+	}
+	return "break $" + label, nil
+}
+
+func checkLabel(label string) error {
+	if !identifierRegexp.MatchString(label) {
+		return fmt.Errorf("invalid jq label %q", label)
+	}
+	return nil
+}
+
+// Limit runs jq's `limit(n; filter)` against jv, returning at most the first n
+// results of filter.
+//
+// An error is returned if n is negative, for which jq would return every
+// result. When n is zero filter isn't run and no results are returned.
+//
+// Compiled programs are cached, keyed on filter. n is passed in alongside jv
+// rather than written into the program.
+//
+// Does not consume the invocant.
+func (jv *Jv) Limit(n int, filter string) ([]*Jv, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("limit: negative count %d", n)
+	}
+	if n == 0 {
+		// libjq 1.6 emits a single result for limit(0; f).
+		return []*Jv{}, nil
+	}
+
+	program := fmt.Sprintf(".[0] as $_limit | .[1] | [limit($_limit; %s)]", filter)
+	return runCachedCollect(program, JvArray().ArrayAppend(JvFromFloat(float64(n))).ArrayAppend(jv.Copy()))
+}
+
+// Until runs jq's `until(cond; update)` against jv, repeatedly applying update
+// until cond is true, and returns the final value.
+//
+// An error is returned if cond does not hold after maxIterations updates, so
+// that a condition that never holds cannot hang the caller.
+//
+// Compiled programs are cached, keyed on both cond and update.
+//
+// Does not consume the invocant.
+func (jv *Jv) Until(cond, update string, maxIterations int) (*Jv, error) {
+	// Pair each value with the number of updates that produced it so that the
+	// loop can be aborted from within jq. The limit is passed in alongside jv
+	// rather than written into the program.
+	program := fmt.Sprintf(
+		`.[1] as $_max | [.[0], 0] | until(.[0] | %s; .[1] as $_i | if $_i >= $_max then error("until: exceeded \($_max) iterations") else .[0] | %s | [., $_i + 1] end) | .[0]`,
+		cond, update,
+	)
+	return runCachedOne(program, JvArray().ArrayAppend(jv.Copy()).ArrayAppend(JvFromFloat(float64(maxIterations))))
+}
+
+// Recurse runs jq's `recurse(filter)` against jv, returning jv followed by
+// every value reached by repeatedly applying filter.
+//
+// An error is returned if the recursion goes deeper than maxDepth, so that a
+// filter that never stops producing values cannot hang the caller.
+//
+// Compiled programs are cached, keyed on filter. maxDepth is passed in
+// alongside jv rather than written into the program.
+//
+// Does not consume the invocant.
+func (jv *Jv) Recurse(filter string, maxDepth int) ([]*Jv, error) {
+	program := fmt.Sprintf(
+		`.[1] as $_max | .[0] | def _recurse($depth): ., (%s | if $depth >= $_max then error("recurse: exceeded maximum depth of \($_max)") else _recurse($depth + 1) end); [_recurse(0)]`,
+		filter,
+	)
+	return runCachedCollect(program, JvArray().ArrayAppend(jv.Copy()).ArrayAppend(JvFromFloat(float64(maxDepth))))
+}
+
+// While runs jq's `while(cond; update)` against jv, returning jv and each
+// successive application of update for as long as cond holds.
+//
+// An error is returned if cond still holds after maxIterations updates.
+//
+// Compiled programs are cached, keyed on both cond and update.
+//
+// Does not consume the invocant.
+func (jv *Jv) While(cond, update string, maxIterations int) ([]*Jv, error) {
+	program := fmt.Sprintf(
+		`[.[1] as $_max | [.[0], 0] | while(.[0] | %s; .[1] as $_i | if $_i >= $_max then error("while: exceeded \($_max) iterations") else .[0] | %s | [., $_i + 1] end) | .[0]]`,
+		cond, update,
+	)
+	return runCachedCollect(program, JvArray().ArrayAppend(jv.Copy()).ArrayAppend(JvFromFloat(float64(maxIterations))))
+}
+
+// AnyMatch runs jq's `any(filter)` against jv, reporting whether filter is
+// true for any element.
+//
+// Compiled programs are cached, keyed on filter.
+//
+// Does not consume the invocant.
+func (jv *Jv) AnyMatch(filter string) (bool, error) {
+	return runCachedBool(fmt.Sprintf("any(%s)", filter), jv.Copy())
+}
+
+// AllMatch runs jq's `all(filter)` against jv, reporting whether filter is
+// true for every element.
+//
+// Compiled programs are cached, keyed on filter.
+//
+// Does not consume the invocant.
+func (jv *Jv) AllMatch(filter string) (bool, error) {
+	return runCachedBool(fmt.Sprintf("all(%s)", filter), jv.Copy())
+}
+
+// Select runs jq's `[.[] | select(filter)]` against an array-typed jv,
+// returning the elements for which filter is true.
+//
+// Returns a *KindError if jv is not an array.
+//
+// Compiled programs are cached, keyed on filter.
+//
+// Does not consume the invocant.
+func (jv *Jv) Select(filter string) ([]*Jv, error) {
+	if jv.Kind() != JvKindArray {
+		return nil, &KindError{Op: "Select", Kind: jv.Kind()}
+	}
+	return runCachedCollect(fmt.Sprintf("[.[] | select(%s)]", filter), jv.Copy())
+}
+
+// Map runs jq's `[.[] | filter]` against an array-typed jv, returning every
+// output of filter for each element in order. A filter that produces several
+// outputs for one element contributes all of them.
+//
+// Returns a *KindError if jv is not an array.
+//
+// Compiled programs are cached, keyed on filter.
+//
+// Does not consume the invocant.
+func (jv *Jv) Map(filter string) ([]*Jv, error) {
+	if jv.Kind() != JvKindArray {
+		return nil, &KindError{Op: "Map", Kind: jv.Kind()}
+	}
+	return runCachedCollect(fmt.Sprintf("[.[] | %s]", filter), jv.Copy())
+}
+
+// MapValues runs jq's `map_values(filter)` against an array or object-typed
+// jv, replacing each value with the result of filter while keeping object
+// keys in place.
+//
+// Returns a *KindError if jv is neither an array nor an object.
+//
+// Compiled programs are cached, keyed on filter.
+//
+// Does not consume the invocant.
+func (jv *Jv) MapValues(filter string) (*Jv, error) {
+	if kind := jv.Kind(); kind != JvKindArray && kind != JvKindObject {
+		return nil, &KindError{Op: "MapValues", Kind: kind}
+	}
+	return runCachedOne(fmt.Sprintf("map_values(%s)", filter), jv.Copy())
+}
+
+// Assert runs the jq program cond against jv and returns a copy of jv if it
+// produces a true value, or an error with the message msg if it produces
+// false or null. It is an error for cond to produce anything other than a
+// single result.
+//
+// Compiled programs are cached, keyed on cond.
+//
+// Does not consume the invocant.
+func (jv *Jv) Assert(cond, msg string) (*Jv, error) {
+	ok, err := runCachedBool(fmt.Sprintf("if (%s) then true else false end", cond), jv.Copy())
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New(msg)
+	}
+	return jv.Copy(), nil
+}
+
+// ToDate runs jq's `todate` against a number-typed jv, converting a Unix
+// timestamp into an ISO-8601 string such as "2015-03-05T23:51:47Z".
+//
+// Returns a *KindError if jv is not a number.
+//
+// Does not consume the invocant.
+func (jv *Jv) ToDate() (*Jv, error) {
+	if jv.Kind() != JvKindNumber {
+		return nil, &KindError{Op: "ToDate", Kind: jv.Kind()}
+	}
+	return runCachedOne("todate", jv.Copy())
+}
+
+// FromDate runs jq's `fromdate` against a string-typed jv, converting an
+// ISO-8601 string such as "2015-03-05T23:51:47Z" into a Unix timestamp.
+//
+// Returns a *KindError if jv is not a string.
+//
+// Does not consume the invocant.
+func (jv *Jv) FromDate() (*Jv, error) {
+	if jv.Kind() != JvKindString {
+		return nil, &KindError{Op: "FromDate", Kind: jv.Kind()}
+	}
+	return runCachedOne("fromdate", jv.Copy())
+}
+
+// Delpaths runs jq's `delpaths(paths)` against jv, returning a copy of jv with
+// the value at each of paths removed.
+//
+// paths is passed to the program alongside jv rather than bound as an
+// argument so that a single compiled program is shared by every call.
+//
+// Returns a *KindError if paths is not an array, or an error if any of its
+// elements is not an array.
+//
+// Consumes paths. Does not consume the invocant.
+func (jv *Jv) Delpaths(paths *Jv) (*Jv, error) {
+	if paths.Kind() != JvKindArray {
+		paths.Free()
+		return nil, &KindError{Op: "Delpaths", Kind: paths.Kind()}
+	}
+
+	len := paths.Copy().ArrayLength()
+	for i := 0; i < len; i++ {
+		path := paths.Copy().ArrayGet(i)
+		kind := path.Kind()
+		path.Free()
+		if kind != JvKindArray {
+			paths.Free()
+			return nil, fmt.Errorf("path %d is of type %s, not array", i, kind)
+		}
+	}
+
+	input := JvArray().ArrayAppend(jv.Copy()).ArrayAppend(paths)
+	return runCachedOne(".[1] as $paths | .[0] | delpaths($paths)", input)
+}
+
+// LeafPathsMatching runs jq's `[path(.. | scalars | select(filter))]`
+// against jv, returning an array of the paths to every leaf value for which
+// filter produces true. As with jq's `leaf_paths`, leaves are the values that
+// are neither arrays nor objects.
+//
+// Compiled programs are cached, keyed on filter.
+//
+// Does not consume the invocant.
+func (jv *Jv) LeafPathsMatching(filter string) (*Jv, error) {
+	return runCachedOne(fmt.Sprintf("[path(.. | scalars | select(%s))]", filter), jv.Copy())
+}
+
+// Walk runs jq's `walk(filter)` against jv, applying filter to every value
+// bottom-up: the elements of arrays and the values of objects are replaced
+// before filter is applied to the array or object containing them.
+//
+// Compiled programs are cached, keyed on filter.
+//
+// Does not consume the invocant.
+func (jv *Jv) Walk(filter string) (*Jv, error) {
+	return runCachedOne(fmt.Sprintf("walk(%s)", filter), jv.Copy())
+}
+
+// env is the environment of the process as returned by Env, read on first
+// use. It holds the strings rather than a jv, because jv reference counts
+// aren't safe to update from several goroutines at once.
+var env struct {
+	sync.Once
+	environ []string
+}
+
+// Env returns jq's `env`: an object mapping the name of each environment
+// variable to its value. It is the equivalent of `$ENV` within a jq program.
+//
+// The environment is read once, on first use, and the same variables are
+// returned for the lifetime of the process.
+//
+// The invocant is not used and is not consumed.
+func (jv *Jv) Env() (*Jv, error) {
+	env.Do(func() {
+		env.environ = os.Environ()
+	})
+	return jvFromEnviron(env.environ), nil
+}
+
+// Debug mirrors jq's `debug`, passing msg and jv to DebugHandler before
+// returning a copy of jv unchanged. msg may be empty.
+//
+// Does not consume the invocant.
+func (jv *Jv) Debug(msg string) *Jv {
+	DebugHandler(msg, jv.Copy())
+	return jv.Copy()
+}
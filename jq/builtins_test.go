@@ -0,0 +1,791 @@
+// Copyright (c) 2018 Jimmy Zelinskie
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package jq_test
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jzelinskie/faq/jq"
+)
+
+func mustParse(t *testing.T, json string) *jq.Jv {
+	jv, err := jq.JvFromJSONString(json)
+	if err != nil {
+		t.Fatalf("error when parsing jv from JSON string %s: %s", json, err)
+	}
+	return jv
+}
+
+func TestJvReduce(t *testing.T) {
+	table := []struct {
+		testName string
+		input    string
+		init     string
+		filter   string
+		output   string
+	}{
+		{"Sum", `[1, 2, 3, 4]`, `0`, `. + $x`, `10`},
+		{"Concat", `["a", "b", "c"]`, `""`, `. + $x`, `"abc"`},
+		{"Object", `[{"k": "a", "v": 1}, {"k": "b", "v": 2}]`, `{}`, `. + {($x.k): $x.v}`, `{"a":1,"b":2}`},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			input := mustParse(t, tt.input)
+			defer input.Free()
+
+			// Run twice so that the second run goes through the program cache.
+			for i := 0; i < 2; i++ {
+				result, err := input.Reduce(mustParse(t, tt.init), tt.filter)
+				if err != nil {
+					t.Fatalf("Reduce() failed: %s", err)
+				}
+				if dump := result.Dump(jq.JvPrintNone); dump != tt.output {
+					t.Errorf("Reduce() got: %s, want: %s", dump, tt.output)
+				}
+			}
+		})
+	}
+}
+
+func TestJvReduceCompileError(t *testing.T) {
+	input := mustParse(t, `[1]`)
+	defer input.Free()
+
+	if _, err := input.Reduce(jq.JvNull(), `. +`); err == nil {
+		t.Errorf("Reduce() with an invalid filter did not return an error")
+	}
+}
+
+func TestJvReduceProgramCacheEviction(t *testing.T) {
+	input := mustParse(t, `[1, 2, 3]`)
+	defer input.Free()
+
+	// Compile more distinct programs than are cached, from several goroutines
+	// at once, so that programs are evicted while others are running.
+	var wg sync.WaitGroup
+	errs := make(chan error, 3)
+	for g := 0; g < 3; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				n := g*50 + i
+				result, err := input.Reduce(jq.JvFromFloat(float64(n)), fmt.Sprintf(". + $x + %d", n))
+				if err != nil {
+					errs <- err
+					return
+				}
+				if dump, want := result.Dump(jq.JvPrintNone), fmt.Sprint(4*n+6); dump != want {
+					errs <- fmt.Errorf("got: %s, want: %s", dump, want)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("Reduce() failed: %s", err)
+	}
+}
+
+func TestJvLabel(t *testing.T) {
+	input := mustParse(t, `[1, 2, 3, 4, 5]`)
+	defer input.Free()
+
+	table := []struct {
+		testName string
+		filter   string
+		output   []string
+	}{
+		{"First", `.[] | ., break $out`, []string{`1`}},
+		{"TakeWhile", `.[] | if . > 3 then break $out else . end`, []string{`1`, `2`, `3`}},
+		{"NoBreak", `.[0]`, []string{`1`}},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			results, err := input.Label("out", tt.filter)
+			if err != nil {
+				t.Fatalf("Label() failed: %s", err)
+			}
+			if len(results) != len(tt.output) {
+				t.Fatalf("Label() got %d results, want: %d", len(results), len(tt.output))
+			}
+			for i, result := range results {
+				if dump := result.Dump(jq.JvPrintNone); dump != tt.output[i] {
+					t.Errorf("Label()[%d] got: %s, want: %s", i, dump, tt.output[i])
+				}
+			}
+		})
+	}
+
+	if _, err := input.Label("$out", `.`); err == nil {
+		t.Errorf("Label() accepted a label with a leading $")
+	}
+}
+
+func TestBreak(t *testing.T) {
+	input := mustParse(t, `[1, 2, 3, 4, 5]`)
+	defer input.Free()
+
+	brk, err := jq.Break("out")
+	if err != nil {
+		t.Fatalf("Break() failed: %s", err)
+	}
+	if brk != `break $out` {
+		t.Errorf("Break() got: %s, want: break $out", brk)
+	}
+
+	results, err := input.Label("out", `.[] | if . > 2 then `+brk+` else . end`)
+	if err != nil {
+		t.Fatalf("Label() with Break() failed: %s", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Label() with Break() got %d results, want: 2", len(results))
+	}
+	for _, result := range results {
+		result.Free()
+	}
+
+	for _, label := range []string{"", "$out", "1out", "out; 1"} {
+		if _, err := jq.Break(label); err == nil {
+			t.Errorf("Break() accepted the invalid label %q", label)
+		}
+	}
+}
+
+func TestJvLimit(t *testing.T) {
+	input := mustParse(t, `[1, 2, 3]`)
+	defer input.Free()
+
+	table := []struct {
+		testName string
+		n        int
+		filter   string
+		output   []string
+	}{
+		{"Zero", 0, `.[]`, []string{}},
+		{"Fewer", 2, `.[]`, []string{`1`, `2`}},
+		{"Exact", 3, `.[]`, []string{`1`, `2`, `3`}},
+		{"More", 10, `.[]`, []string{`1`, `2`, `3`}},
+		{"Infinite", 2, `range(1; infinite)`, []string{`1`, `2`}},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			results, err := input.Limit(tt.n, tt.filter)
+			if err != nil {
+				t.Fatalf("Limit() failed: %s", err)
+			}
+			if len(results) != len(tt.output) {
+				t.Fatalf("Limit() got %d results, want: %d", len(results), len(tt.output))
+			}
+			for i, result := range results {
+				if dump := result.Dump(jq.JvPrintNone); dump != tt.output[i] {
+					t.Errorf("Limit()[%d] got: %s, want: %s", i, dump, tt.output[i])
+				}
+			}
+		})
+	}
+
+	if _, err := input.Limit(-1, `.[]`); err == nil {
+		t.Errorf("Limit() accepted a negative count")
+	}
+}
+
+func TestJvUntil(t *testing.T) {
+	table := []struct {
+		testName string
+		input    string
+		cond     string
+		update   string
+		output   string
+	}{
+		{
+			"Collatz",
+			`{"n": 27, "steps": 0}`,
+			`.n == 1`,
+			`{n: (if .n % 2 == 0 then .n / 2 else 3 * .n + 1 end), steps: (.steps + 1)}`,
+			`{"n":1,"steps":111}`,
+		},
+		{
+			"Newton",
+			`{"x": 1}`,
+			`(.x * .x - 2) | (. < 1e-12 and . > -1e-12)`,
+			`.x = (.x + 2 / .x) / 2`,
+			`{"x":1.414213562373095}`,
+		},
+		{"AlreadyTrue", `5`, `. > 1`, `. + 1`, `5`},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			input := mustParse(t, tt.input)
+			defer input.Free()
+
+			result, err := input.Until(tt.cond, tt.update, 1000)
+			if err != nil {
+				t.Fatalf("Until() failed: %s", err)
+			}
+			if dump := result.Dump(jq.JvPrintNone); dump != tt.output {
+				t.Errorf("Until() got: %s, want: %s", dump, tt.output)
+			}
+		})
+	}
+}
+
+func TestJvUntilMaxIterations(t *testing.T) {
+	input := jq.JvFromFloat(0)
+	defer input.Free()
+
+	result, err := input.Until(`false`, `. + 1`, 10)
+	if want := "until: exceeded 10 iterations"; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Until() with a condition that never holds got error: %v, want: %s", err, want)
+	}
+
+	result, err = input.Until(`. == 10`, `. + 1`, 10)
+	if err != nil {
+		t.Fatalf("Until() within maxIterations failed: %s", err)
+	}
+	if dump := result.Dump(jq.JvPrintNone); dump != `10` {
+		t.Errorf("Until() got: %s, want: 10", dump)
+	}
+}
+
+func TestJvRecurse(t *testing.T) {
+	input := mustParse(t, `{
+		"name": "/",
+		"children": [
+			{"name": "bin", "children": []},
+			{"name": "home", "children": [
+				{"name": "jzelinskie", "children": [
+					{"name": ".bashrc"}
+				]}
+			]}
+		]
+	}`)
+	defer input.Free()
+
+	results, err := input.Recurse(`.children[]?`, 1000)
+	if err != nil {
+		t.Fatalf("Recurse() failed: %s", err)
+	}
+
+	want := []string{"/", "bin", "home", "jzelinskie", ".bashrc"}
+	if len(results) != len(want) {
+		t.Fatalf("Recurse() got %d results, want: %d", len(results), len(want))
+	}
+	for i, result := range results {
+		m, err := result.ToMap()
+		if err != nil {
+			t.Fatalf("Recurse()[%d] is not an object: %s", i, err)
+		}
+		if m["name"] != want[i] {
+			t.Errorf("Recurse()[%d] got name: %v, want: %s", i, m["name"], want[i])
+		}
+		result.Free()
+	}
+}
+
+func TestJvRecurseMaxDepth(t *testing.T) {
+	input := jq.JvFromFloat(0)
+	defer input.Free()
+
+	_, err := input.Recurse(`. + 1`, 10)
+	if err == nil {
+		t.Fatalf("Recurse() with an infinite filter did not return an error")
+	}
+	if !strings.Contains(err.Error(), "exceeded maximum depth of 10") {
+		t.Errorf("Recurse() got error: %s, want it to mention the depth limit", err)
+	}
+
+	results, err := input.Recurse(`select(. < 10) | . + 1`, 10)
+	if err != nil {
+		t.Fatalf("Recurse() within maxDepth failed: %s", err)
+	}
+	if len(results) != 11 {
+		t.Errorf("Recurse() got %d results, want: 11", len(results))
+	}
+}
+
+func TestJvWhile(t *testing.T) {
+	table := []struct {
+		testName string
+		input    string
+		cond     string
+		update   string
+		output   []string
+	}{
+		{"Fibonacci", `[0, 1]`, `.[0] < 20`, `[.[1], .[0] + .[1]]`, []string{`[0,1]`, `[1,1]`, `[1,2]`, `[2,3]`, `[3,5]`, `[5,8]`, `[8,13]`, `[13,21]`}},
+		{"Geometric", `1`, `. < 100`, `. * 3`, []string{`1`, `3`, `9`, `27`, `81`}},
+		{"NeverTrue", `1`, `. > 1`, `. + 1`, []string{}},
+		{"Branching", `[0]`, `length < 3`, `. + [0], . + [1]`, []string{`[0]`, `[0,0]`, `[0,1]`}},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			input := mustParse(t, tt.input)
+			defer input.Free()
+
+			results, err := input.While(tt.cond, tt.update, 1000)
+			if err != nil {
+				t.Fatalf("While() failed: %s", err)
+			}
+			if len(results) != len(tt.output) {
+				t.Fatalf("While() got %d results, want: %d", len(results), len(tt.output))
+			}
+			for i, result := range results {
+				if dump := result.Dump(jq.JvPrintNone); dump != tt.output[i] {
+					t.Errorf("While()[%d] got: %s, want: %s", i, dump, tt.output[i])
+				}
+			}
+		})
+	}
+}
+
+func TestJvWhileMaxIterations(t *testing.T) {
+	input := jq.JvFromFloat(0)
+	defer input.Free()
+
+	if _, err := input.While(`true`, `. + 1`, 10); err == nil {
+		t.Errorf("While() with a condition that always holds did not return an error")
+	}
+}
+
+func TestJvAnyAllMatch(t *testing.T) {
+	table := []struct {
+		testName string
+		input    string
+		filter   string
+		any      bool
+		all      bool
+	}{
+		{"Mixed", `[1, 5, 10]`, `. > 4`, true, false},
+		{"AllMatch", `[5, 6, 7]`, `. > 4`, true, true},
+		{"NoneMatch", `[1, 2, 3]`, `. > 4`, false, false},
+		{"Empty", `[]`, `. > 4`, false, true},
+		{"Objects", `[{"ok": true}, {"ok": false}]`, `.ok`, true, false},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			input := mustParse(t, tt.input)
+			defer input.Free()
+
+			any, err := input.AnyMatch(tt.filter)
+			if err != nil {
+				t.Fatalf("AnyMatch() failed: %s", err)
+			}
+			if any != tt.any {
+				t.Errorf("AnyMatch() got: %t, want: %t", any, tt.any)
+			}
+
+			all, err := input.AllMatch(tt.filter)
+			if err != nil {
+				t.Fatalf("AllMatch() failed: %s", err)
+			}
+			if all != tt.all {
+				t.Errorf("AllMatch() got: %t, want: %t", all, tt.all)
+			}
+		})
+	}
+}
+
+func TestJvSelect(t *testing.T) {
+	table := []struct {
+		testName string
+		input    string
+		filter   string
+		output   []string
+	}{
+		{"Numbers", `[3, 6, 5, 9]`, `. > 5`, []string{`6`, `9`}},
+		{"Objects", `[{"name": "test", "id": 1}, {"name": "prod", "id": 2}]`, `.name == "test"`, []string{`{"name":"test","id":1}`}},
+		{"NoMatch", `[1, 2]`, `. > 5`, []string{}},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			input := mustParse(t, tt.input)
+			defer input.Free()
+
+			results, err := input.Select(tt.filter)
+			if err != nil {
+				t.Fatalf("Select() failed: %s", err)
+			}
+			if len(results) != len(tt.output) {
+				t.Fatalf("Select() got %d results, want: %d", len(results), len(tt.output))
+			}
+			for i, result := range results {
+				if dump := result.Dump(jq.JvPrintNone); dump != tt.output[i] {
+					t.Errorf("Select()[%d] got: %s, want: %s", i, dump, tt.output[i])
+				}
+			}
+		})
+	}
+
+	obj := jq.JvObject()
+	defer obj.Free()
+	if _, err := obj.Select(`true`); err == nil {
+		t.Errorf("Select() on an object did not return an error")
+	}
+}
+
+func TestJvMap(t *testing.T) {
+	table := []struct {
+		testName string
+		input    string
+		filter   string
+		output   []string
+	}{
+		{"Numbers", `[1, 2, 3]`, `. * 2`, []string{`2`, `4`, `6`}},
+		{"Field", `[{"name": "test"}, {"name": "prod"}]`, `.name`, []string{`"test"`, `"prod"`}},
+		{"MultipleOutputs", `[1, 2]`, `., . * 10`, []string{`1`, `10`, `2`, `20`}},
+		{"Iterate", `[[1, 2], [], [3]]`, `.[]`, []string{`1`, `2`, `3`}},
+		{"Empty", `[1, 2]`, `empty`, []string{}},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			input := mustParse(t, tt.input)
+			defer input.Free()
+
+			results, err := input.Map(tt.filter)
+			if err != nil {
+				t.Fatalf("Map() failed: %s", err)
+			}
+			if len(results) != len(tt.output) {
+				t.Fatalf("Map() got %d results, want: %d", len(results), len(tt.output))
+			}
+			for i, result := range results {
+				if dump := result.Dump(jq.JvPrintNone); dump != tt.output[i] {
+					t.Errorf("Map()[%d] got: %s, want: %s", i, dump, tt.output[i])
+				}
+			}
+		})
+	}
+
+	obj := jq.JvObject()
+	defer obj.Free()
+	if _, err := obj.Map(`.`); err == nil {
+		t.Errorf("Map() on an object did not return an error")
+	}
+}
+
+func TestJvMapValues(t *testing.T) {
+	table := []struct {
+		testName string
+		input    string
+		filter   string
+		output   string
+	}{
+		{"Array", `[1, 2, 3]`, `. + 1`, `[2,3,4]`},
+		{"Object", `{"b": 1, "a": 2}`, `. * 10`, `{"b":10,"a":20}`},
+		{"ObjectStrings", `{"name": "test", "env": "prod"}`, `ascii_upcase`, `{"name":"TEST","env":"PROD"}`},
+		{"Empty", `{}`, `. + 1`, `{}`},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			input := mustParse(t, tt.input)
+			defer input.Free()
+
+			result, err := input.MapValues(tt.filter)
+			if err != nil {
+				t.Fatalf("MapValues() failed: %s", err)
+			}
+			if dump := result.Dump(jq.JvPrintNone); dump != tt.output {
+				t.Errorf("MapValues() got: %s, want: %s", dump, tt.output)
+			}
+		})
+	}
+
+	num := jq.JvFromFloat(1)
+	defer num.Free()
+	if _, err := num.MapValues(`.`); err == nil {
+		t.Errorf("MapValues() on a number did not return an error")
+	}
+}
+
+func TestJvAssert(t *testing.T) {
+	input := mustParse(t, `{"name": "jimmy", "age": 30, "email": null}`)
+	defer input.Free()
+
+	table := []struct {
+		testName string
+		cond     string
+		err      string
+	}{
+		{"True", `.age > 0`, ""},
+		{"Truthy", `.name`, ""},
+		{"False", `.age > 100`, "assertion failed"},
+		{"Null", `.email`, "assertion failed"},
+		{"MultipleResults", `.age, .name`, "jq program `if (.age, .name) then true else false end` produced 2 results, expected 1"},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			result, err := input.Assert(tt.cond, "assertion failed")
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("Assert() failed: %s", err)
+				}
+				if dump := result.Dump(jq.JvPrintNone); dump != `{"name":"jimmy","age":30,"email":null}` {
+					t.Errorf("Assert() got: %s, want the input unchanged", dump)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("Assert() got error: %v, want: %s", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestJvDates(t *testing.T) {
+	table := []struct {
+		testName  string
+		timestamp float64
+		date      string
+	}{
+		{"Epoch", 0, `"1970-01-01T00:00:00Z"`},
+		{"Recent", 1425599507, `"2015-03-05T23:51:47Z"`},
+		{"FarFuture", 32503680000, `"3000-01-01T00:00:00Z"`},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			timestamp := jq.JvFromFloat(tt.timestamp)
+			defer timestamp.Free()
+
+			date, err := timestamp.ToDate()
+			if err != nil {
+				t.Fatalf("ToDate() failed: %s", err)
+			}
+			defer date.Free()
+			if dump := date.Copy().Dump(jq.JvPrintNone); dump != tt.date {
+				t.Errorf("ToDate() got: %s, want: %s", dump, tt.date)
+			}
+
+			roundTrip, err := date.FromDate()
+			if err != nil {
+				t.Fatalf("FromDate() failed: %s", err)
+			}
+			want := strconv.FormatFloat(tt.timestamp, 'f', -1, 64)
+			if dump := roundTrip.Dump(jq.JvPrintNone); dump != want {
+				t.Errorf("FromDate() got: %s, want: %s", dump, want)
+			}
+		})
+	}
+
+	str := jq.JvFromString("0")
+	defer str.Free()
+	if _, err := str.ToDate(); err == nil {
+		t.Errorf("ToDate() on a string did not return an error")
+	}
+
+	num := jq.JvFromFloat(0)
+	defer num.Free()
+	if _, err := num.FromDate(); err == nil {
+		t.Errorf("FromDate() on a number did not return an error")
+	}
+
+	invalid := jq.JvFromString("not a date")
+	defer invalid.Free()
+	if _, err := invalid.FromDate(); err == nil {
+		t.Errorf("FromDate() with an invalid date did not return an error")
+	}
+}
+
+func TestJvDelpaths(t *testing.T) {
+	input := mustParse(t, `{"a": {"b": 1, "c": 2}, "d": [1, 2, 3], "e": 4}`)
+	defer input.Free()
+
+	table := []struct {
+		testName string
+		paths    string
+		output   string
+	}{
+		{"None", `[]`, `{"a":{"b":1,"c":2},"d":[1,2,3],"e":4}`},
+		{"Siblings", `[["a", "b"], ["a", "c"]]`, `{"a":{},"d":[1,2,3],"e":4}`},
+		{"ParentAndChild", `[["a"], ["a", "b"]]`, `{"d":[1,2,3],"e":4}`},
+		{"ArrayElements", `[["d", 0], ["d", 2]]`, `{"a":{"b":1,"c":2},"d":[2],"e":4}`},
+		{"Missing", `[["x", "y"]]`, `{"a":{"b":1,"c":2},"d":[1,2,3],"e":4}`},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			result, err := input.Delpaths(mustParse(t, tt.paths))
+			if err != nil {
+				t.Fatalf("Delpaths() failed: %s", err)
+			}
+			if dump := result.Dump(jq.JvPrintNone); dump != tt.output {
+				t.Errorf("Delpaths() got: %s, want: %s", dump, tt.output)
+			}
+		})
+	}
+
+	if _, err := input.Delpaths(mustParse(t, `["a"]`)); err == nil {
+		t.Errorf("Delpaths() with a path that is not an array did not return an error")
+	}
+	if _, err := input.Delpaths(mustParse(t, `{}`)); err == nil {
+		t.Errorf("Delpaths() with paths that is not an array did not return an error")
+	}
+}
+
+func TestJvLeafPathsMatching(t *testing.T) {
+	input := mustParse(t, `{"name": "web", "replicas": 3, "labels": {"app": "web", "tier": null}, "ports": [80, 443]}`)
+	defer input.Free()
+
+	table := []struct {
+		testName string
+		filter   string
+		output   string
+	}{
+		{"Strings", `type == "string"`, `[["name"],["labels","app"]]`},
+		{"NonNull", `. != null`, `[["name"],["replicas"],["labels","app"],["ports",0],["ports",1]]`},
+		{"GreaterThan", `type == "number" and . > 50`, `[["ports",0],["ports",1]]`},
+		{"None", `false`, `[]`},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			result, err := input.LeafPathsMatching(tt.filter)
+			if err != nil {
+				t.Fatalf("LeafPathsMatching() failed: %s", err)
+			}
+			if dump := result.Dump(jq.JvPrintNone); dump != tt.output {
+				t.Errorf("LeafPathsMatching() got: %s, want: %s", dump, tt.output)
+			}
+		})
+	}
+}
+
+func TestJvWalk(t *testing.T) {
+	table := []struct {
+		testName string
+		input    string
+		filter   string
+		output   string
+	}{
+		{"StringsToNumbers", `{"replicas": "3", "name": "web", "ports": ["80", 443]}`, `if type == "string" then tonumber? // . else . end`, `{"replicas":3,"name":"web","ports":[80,443]}`},
+		{"Redact", `{"user": "jimmy", "password": "hunter2", "db": {"password": "secret", "port": 5432}}`, `if type == "object" and has("password") then .password = "REDACTED" else . end`, `{"user":"jimmy","password":"REDACTED","db":{"password":"REDACTED","port":5432}}`},
+		{"BottomUp", `[[3, 1], [2]]`, `if type == "array" then sort else . end`, `[[1,3],[2]]`},
+		{"Scalar", `1`, `. + 1`, `2`},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			input := mustParse(t, tt.input)
+			defer input.Free()
+
+			result, err := input.Walk(tt.filter)
+			if err != nil {
+				t.Fatalf("Walk() failed: %s", err)
+			}
+			if dump := result.Dump(jq.JvPrintNone); dump != tt.output {
+				t.Errorf("Walk() got: %s, want: %s", dump, tt.output)
+			}
+		})
+	}
+}
+
+func TestJvEnv(t *testing.T) {
+	os.Setenv("FAQ_TEST_ENV", "value=with=equals")
+
+	env, err := jq.JvNull().Env()
+	if err != nil {
+		t.Fatalf("Env() failed: %s", err)
+	}
+	value, err := env.Getpath(jq.JvFromStringSlice([]string{"FAQ_TEST_ENV"}))
+	env.Free()
+	if err != nil {
+		t.Fatalf("Env() failed to get FAQ_TEST_ENV: %s", err)
+	}
+	if str, _ := value.StringValue(); str != "value=with=equals" {
+		t.Errorf("Env() got FAQ_TEST_ENV: %q, want: %q", str, "value=with=equals")
+	}
+
+	// Later changes to the environment are not seen.
+	os.Setenv("FAQ_TEST_ENV", "changed")
+	defer os.Unsetenv("FAQ_TEST_ENV")
+
+	env, _ = jq.JvNull().Env()
+	value, _ = env.Getpath(jq.JvFromStringSlice([]string{"FAQ_TEST_ENV"}))
+	env.Free()
+	if str, _ := value.StringValue(); str != "value=with=equals" {
+		t.Errorf("Env() got FAQ_TEST_ENV: %q after it changed, want: %q", str, "value=with=equals")
+	}
+}
+
+func TestJvEnvConcurrent(t *testing.T) {
+	// Each call builds its own object, so callers on different goroutines
+	// never share reference counts.
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				env, err := jq.JvNull().Env()
+				if err != nil {
+					t.Errorf("Env() failed: %s", err)
+					return
+				}
+				env.Free()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestJvDebug(t *testing.T) {
+	var messages, dumps []string
+	defer func(handler func(string, *jq.Jv)) { jq.DebugHandler = handler }(jq.DebugHandler)
+	jq.DebugHandler = func(msg string, jv *jq.Jv) {
+		messages = append(messages, msg)
+		dumps = append(dumps, jv.Dump(jq.JvPrintNone))
+	}
+
+	input := mustParse(t, `{"a": [1, 2]}`)
+	defer input.Free()
+
+	result := input.Debug("before").Debug("")
+	if dump := result.Dump(jq.JvPrintNone); dump != `{"a":[1,2]}` {
+		t.Errorf("Debug() got: %s, want: %s", dump, `{"a":[1,2]}`)
+	}
+
+	if len(messages) != 2 || messages[0] != "before" || messages[1] != "" {
+		t.Errorf("Debug() passed messages: %q, want: %q", messages, []string{"before", ""})
+	}
+	for _, dump := range dumps {
+		if dump != `{"a":[1,2]}` {
+			t.Errorf("Debug() passed value: %s, want: %s", dump, `{"a":[1,2]}`)
+		}
+	}
+}
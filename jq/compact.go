@@ -0,0 +1,250 @@
+// Copyright (c) 2018 Jimmy Zelinskie
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package jq
+
+/*
+#include <jv.h>
+*/
+import "C"
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// The tokens written by Compact. They are allocated once so that writing them
+// doesn't allocate.
+var (
+	compactNull       = []byte("null")
+	compactTrue       = []byte("true")
+	compactFalse      = []byte("false")
+	compactArrayOpen  = []byte("[")
+	compactArrayClose = []byte("]")
+	compactObjectOpen = []byte("{")
+	compactObjectEnd  = []byte("}")
+	compactComma      = []byte(",")
+	compactColon      = []byte(":")
+)
+
+// Compact writes jv to w as compact JSON, the same as Dump(JvPrintNone), but
+// without building the whole document as a string first: the tree is walked
+// in Go and each token is written to w as it is reached. w should be buffered
+// if its writes are expensive.
+//
+// Returns the first error from writing to w, or an error if jv is invalid.
+//
+// Does not consume the invocant.
+func (jv *Jv) Compact(w io.Writer) error {
+	if !jv.IsValid() {
+		return errors.New("Compact: jv is invalid")
+	}
+
+	c := &compactor{w: w}
+	c.value(jv.jv)
+	return c.err
+}
+
+// compactor writes the tokens of a Jv, keeping the first error from doing so
+// and the buffers reused to format scalars.
+type compactor struct {
+	w      io.Writer
+	err    error
+	buf    []byte
+	digits []byte
+}
+
+// write writes b to the writer, unless an earlier write failed.
+func (c *compactor) write(b []byte) {
+	if c.err == nil {
+		_, c.err = c.w.Write(b)
+	}
+}
+
+// value writes jv.
+//
+// Does not consume jv.
+func (c *compactor) value(jv C.jv) {
+	if c.err != nil {
+		return
+	}
+
+	switch C.jv_get_kind(jv) {
+	case C.JV_KIND_NULL:
+		c.write(compactNull)
+	case C.JV_KIND_TRUE:
+		c.write(compactTrue)
+	case C.JV_KIND_FALSE:
+		c.write(compactFalse)
+	case C.JV_KIND_NUMBER:
+		c.number(float64(C.jv_number_value(jv)))
+	case C.JV_KIND_STRING:
+		c.str(stringBytes(jv))
+	case C.JV_KIND_ARRAY:
+		c.write(compactArrayOpen)
+		length := int(C.jv_array_length(C.jv_copy(jv)))
+		for i := 0; i < length && c.err == nil; i++ {
+			if i > 0 {
+				c.write(compactComma)
+			}
+			elem := C.jv_array_get(C.jv_copy(jv), C.int(i))
+			c.value(elem)
+			C.jv_free(elem)
+		}
+		c.write(compactArrayClose)
+	case C.JV_KIND_OBJECT:
+		c.write(compactObjectOpen)
+		first := true
+		for iter := C.jv_object_iter(jv); C.jv_object_iter_valid(jv, iter) != 0 && c.err == nil; iter = C.jv_object_iter_next(jv, iter) {
+			if !first {
+				c.write(compactComma)
+			}
+			first = false
+
+			key := C.jv_object_iter_key(jv, iter)
+			c.str(stringBytes(key))
+			C.jv_free(key)
+			c.write(compactColon)
+
+			value := C.jv_object_iter_value(jv, iter)
+			c.value(value)
+			C.jv_free(value)
+		}
+		c.write(compactObjectEnd)
+	}
+}
+
+// stringBytes returns the bytes of a string-typed jv, including any NUL
+// characters. The bytes belong to jv, so they must not be used once it is
+// freed.
+//
+// Does not consume jv.
+func stringBytes(jv C.jv) []byte {
+	length := int(C.jv_string_length_bytes(C.jv_copy(jv)))
+	if length == 0 {
+		return nil
+	}
+	return (*[1 << 30]byte)(unsafe.Pointer(C.jv_string_value(jv)))[:length:length]
+}
+
+// number writes f as libjq prints it: with the fewest digits that identify it,
+// using an exponent of at least two digits if it is below 1e-4 or has more
+// than 15 zeros before the decimal point. NaN is printed as null, and the
+// infinities as the largest finite numbers.
+func (c *compactor) number(f float64) {
+	if math.IsNaN(f) {
+		c.write(compactNull)
+		return
+	}
+	if math.IsInf(f, 0) {
+		f = math.Copysign(math.MaxFloat64, f)
+	}
+
+	// Split f into its digits and the position of the decimal point relative
+	// to them, from the form d.ddde±xx.
+	c.digits = strconv.AppendFloat(c.digits[:0], math.Abs(f), 'e', -1, 64)
+	e := bytes.IndexByte(c.digits, 'e')
+	exp, _ := strconv.Atoi(string(c.digits[e+1:]))
+	digits := c.digits[:1]
+	if e > 1 {
+		digits = append(digits, c.digits[2:e]...)
+	}
+	decpt := exp + 1
+
+	c.buf = c.buf[:0]
+	if math.Signbit(f) {
+		c.buf = append(c.buf, '-')
+	}
+	switch {
+	case decpt <= -4 || decpt > len(digits)+15:
+		c.buf = append(c.buf, digits[0])
+		if len(digits) > 1 {
+			c.buf = append(c.buf, '.')
+			c.buf = append(c.buf, digits[1:]...)
+		}
+		c.buf = append(c.buf, 'e')
+		if exp < 0 {
+			c.buf = append(c.buf, '-')
+			exp = -exp
+		} else {
+			c.buf = append(c.buf, '+')
+		}
+		if exp < 10 {
+			c.buf = append(c.buf, '0')
+		}
+		c.buf = strconv.AppendInt(c.buf, int64(exp), 10)
+	case decpt <= 0:
+		c.buf = append(c.buf, '0', '.')
+		for ; decpt < 0; decpt++ {
+			c.buf = append(c.buf, '0')
+		}
+		c.buf = append(c.buf, digits...)
+	case decpt >= len(digits):
+		c.buf = append(c.buf, digits...)
+		for i := len(digits); i < decpt; i++ {
+			c.buf = append(c.buf, '0')
+		}
+	default:
+		c.buf = append(c.buf, digits[:decpt]...)
+		c.buf = append(c.buf, '.')
+		c.buf = append(c.buf, digits[decpt:]...)
+	}
+	c.write(c.buf)
+}
+
+// str writes s as a JSON string, escaped as libjq does.
+func (c *compactor) str(s []byte) {
+	c.buf = append(c.buf[:0], '"')
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRune(s[i:])
+		switch r {
+		case '"':
+			c.buf = append(c.buf, '\\', '"')
+		case '\\':
+			c.buf = append(c.buf, '\\', '\\')
+		case '\b':
+			c.buf = append(c.buf, '\\', 'b')
+		case '\f':
+			c.buf = append(c.buf, '\\', 'f')
+		case '\n':
+			c.buf = append(c.buf, '\\', 'n')
+		case '\r':
+			c.buf = append(c.buf, '\\', 'r')
+		case '\t':
+			c.buf = append(c.buf, '\\', 't')
+		default:
+			if r < 0x20 || r == 0x7f {
+				c.buf = append(c.buf, '\\', 'u', '0', '0', hexDigits[r>>4], hexDigits[r&0xf])
+			} else {
+				c.buf = append(c.buf, s[i:i+size]...)
+			}
+		}
+		i += size
+	}
+	c.buf = append(c.buf, '"')
+	c.write(c.buf)
+}
+
+// hexDigits are the lowercase hexadecimal digits used in \u escapes.
+const hexDigits = "0123456789abcdef"
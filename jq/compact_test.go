@@ -0,0 +1,167 @@
+// Copyright (c) 2018 Jimmy Zelinskie
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package jq_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"testing"
+
+	"github.com/jzelinskie/faq/jq"
+)
+
+func TestJvCompact(t *testing.T) {
+	table := []struct {
+		testName string
+		input    string
+	}{
+		{"Null", `null`},
+		{"Booleans", `[true,false]`},
+		{"Integers", `[0,-0,1,-17,1000000,1e15,9999999999999998,1e16,-1e16,123456789012345678]`},
+		{"Numbers", `[1.5,-0.25,1e-5,0.0001,1e300,3.141592653589793]`},
+		{"Strings", `["", "faq", "\u0000\u001f\u007f\u0080/é😀", "\b\f\n\r\t\\\""]`},
+		{"EmptyContainers", `[[],{},[{}]]`},
+		{"Object", `{"z":1,"a":{"k\"ey":[1,{"b":null}]},"m":"s","\u0000":0}`},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			input := mustParse(t, tt.input)
+			defer input.Free()
+
+			var b bytes.Buffer
+			if err := input.Compact(&b); err != nil {
+				t.Fatalf("Compact() failed: %s", err)
+			}
+			if want := input.Copy().Dump(jq.JvPrintNone); b.String() != want {
+				t.Errorf("Compact() got: %s, want: %s", b.String(), want)
+			}
+		})
+	}
+}
+
+func TestJvCompactNonFinite(t *testing.T) {
+	input := jq.JvArray().
+		ArrayAppend(jq.JvFromFloat(math.NaN())).
+		ArrayAppend(jq.JvFromFloat(math.Inf(1))).
+		ArrayAppend(jq.JvFromFloat(math.Inf(-1)))
+	defer input.Free()
+
+	var b bytes.Buffer
+	if err := input.Compact(&b); err != nil {
+		t.Fatalf("Compact() failed: %s", err)
+	}
+	if want := input.Copy().Dump(jq.JvPrintNone); b.String() != want {
+		t.Errorf("Compact() got: %s, want: %s", b.String(), want)
+	}
+}
+
+func TestJvCompactNumbers(t *testing.T) {
+	var values []float64
+	for exp := -330; exp <= 310; exp++ {
+		for _, mantissa := range []float64{1, 1.5, 2.5e-7, 3.14159, 7.0 / 3, 9.999999999999999} {
+			values = append(values, mantissa*math.Pow(10, float64(exp)), -mantissa*math.Pow(10, float64(exp)))
+		}
+	}
+	values = append(values, 0, math.Copysign(0, -1), math.MaxFloat64, math.SmallestNonzeroFloat64, 1<<53, 1<<53+1)
+
+	for _, value := range values {
+		input := jq.JvFromFloat(value)
+		var b bytes.Buffer
+		if err := input.Compact(&b); err != nil {
+			t.Fatalf("Compact() failed: %s", err)
+		}
+		if want := input.Dump(jq.JvPrintNone); b.String() != want {
+			t.Errorf("Compact() of %v got: %s, want: %s", value, b.String(), want)
+		}
+	}
+}
+
+// failingWriter fails once more than n bytes have been written to it.
+type failingWriter struct {
+	n int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.n -= len(p); w.n < 0 {
+		return 0, errors.New("write failed")
+	}
+	return len(p), nil
+}
+
+func TestJvCompactErrors(t *testing.T) {
+	input := mustParse(t, `{"a":[1,2,{"b":"c"}],"d":true}`)
+	defer input.Free()
+
+	if err := input.Compact(&failingWriter{n: 10}); err == nil || err.Error() != "write failed" {
+		t.Errorf("Compact() got error: %v, want: write failed", err)
+	}
+
+	if err := jq.JvInvalid().Compact(ioutil.Discard); err == nil {
+		t.Error("Compact() of an invalid jv unexpectedly succeeded")
+	}
+}
+
+// benchmarkDocument returns an array-typed jv whose compact JSON is about
+// size bytes.
+func benchmarkDocument(size int) *jq.Jv {
+	ary := jq.JvArray()
+	for i := 0; i*64 < size; i++ {
+		obj := jq.JvObject().
+			ObjectSet(jq.JvFromString("id"), jq.JvFromFloat(float64(i))).
+			ObjectSet(jq.JvFromString("name"), jq.JvFromString(fmt.Sprintf("user %d", i))).
+			ObjectSet(jq.JvFromString("score"), jq.JvFromFloat(float64(i)/7)).
+			ObjectSet(jq.JvFromString("active"), jq.JvFromBool(i%2 == 0))
+		ary = ary.ArrayAppend(obj)
+	}
+	return ary
+}
+
+func BenchmarkJvCompact(b *testing.B) {
+	input := benchmarkDocument(1 << 20)
+	defer input.Free()
+	b.SetBytes(int64(len(input.Copy().Dump(jq.JvPrintNone))))
+	b.ReportAllocs()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := input.Compact(ioutil.Discard); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkJvDumpCompact(b *testing.B) {
+	input := benchmarkDocument(1 << 20)
+	defer input.Free()
+	b.SetBytes(int64(len(input.Copy().Dump(jq.JvPrintNone))))
+	b.ReportAllocs()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ioutil.Discard.Write([]byte(input.Copy().Dump(jq.JvPrintNone))); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
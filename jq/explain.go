@@ -0,0 +1,184 @@
+// Copyright (c) 2018 Jimmy Zelinskie
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package jq
+
+import (
+	"fmt"
+	"strings"
+)
+
+// explainPreviewLen is the number of keys or elements included in the preview
+// of an object or array by Explain.
+const explainPreviewLen = 3
+
+// explainValueLen is the length beyond which values are truncated in the
+// previews produced by Explain and ExplainVerbose.
+const explainValueLen = 40
+
+// Explain returns a human-readable description of the structure of jv, such
+// as:
+//
+//	object(5 keys, depth 3): {name, age, address, ...}
+//	9 leaves
+//
+// Leaves are the values that are neither arrays nor objects. Scalars are
+// described on a single line, such as `number: 42`.
+//
+// Does not consume the invocant.
+func (jv *Jv) Explain() string {
+	kind := jv.Kind()
+	if kind != JvKindArray && kind != JvKindObject {
+		return fmt.Sprintf("%s: %s", kind, preview(jv))
+	}
+
+	depth, leaves := jvShape(jv)
+
+	var summary string
+	if kind == JvKindObject {
+		var keys []string
+		jv.ObjectForEach(func(key string, _ *Jv) error {
+			keys = append(keys, key)
+			return nil
+		})
+		summary = fmt.Sprintf("object(%s, depth %d): {%s}", plural(len(keys), "key", "keys"), depth, previewList(keys))
+	} else {
+		len := jv.Copy().ArrayLength()
+		var elems []string
+		for i := 0; i < len && i <= explainPreviewLen; i++ {
+			elem := jv.Copy().ArrayGet(i)
+			elems = append(elems, preview(elem))
+			elem.Free()
+		}
+		summary = fmt.Sprintf("array(%s, depth %d): [%s]", plural(len, "element", "elements"), depth, previewList(elems))
+	}
+
+	return summary + "\n" + plural(leaves, "leaf", "leaves")
+}
+
+// ExplainVerbose is like Explain, but follows the description with a line for
+// each leaf giving its path and a preview of its value.
+//
+// Does not consume the invocant.
+func (jv *Jv) ExplainVerbose() string {
+	lines := []string{jv.Explain()}
+	eachLeaf(jv, "", func(path string, leaf *Jv) {
+		if path == "" {
+			path = "."
+		}
+		lines = append(lines, fmt.Sprintf("  %s: %s", path, preview(leaf)))
+	})
+	return strings.Join(lines, "\n")
+}
+
+// jvShape returns how deeply arrays and objects are nested within jv and the
+// number of leaves it contains.
+//
+// Does not consume jv.
+func jvShape(jv *Jv) (depth, leaves int) {
+	switch jv.Kind() {
+	case JvKindObject:
+		jv.ObjectForEach(func(_ string, value *Jv) error {
+			d, l := jvShape(value)
+			if d > depth {
+				depth = d
+			}
+			leaves += l
+			return nil
+		})
+		return depth + 1, leaves
+	case JvKindArray:
+		len := jv.Copy().ArrayLength()
+		for i := 0; i < len; i++ {
+			elem := jv.Copy().ArrayGet(i)
+			d, l := jvShape(elem)
+			elem.Free()
+			if d > depth {
+				depth = d
+			}
+			leaves += l
+		}
+		return depth + 1, leaves
+	default:
+		return 0, 1
+	}
+}
+
+// eachLeaf calls fn with the path to and value of each leaf within jv, in
+// order. The value passed to fn is freed once fn returns.
+//
+// Does not consume jv.
+func eachLeaf(jv *Jv, path string, fn func(path string, leaf *Jv)) {
+	switch jv.Kind() {
+	case JvKindObject:
+		jv.ObjectForEach(func(key string, value *Jv) error {
+			if identifierRegexp.MatchString(key) {
+				eachLeaf(value, path+"."+key, fn)
+			} else {
+				eachLeaf(value, path+"["+JvFromString(key).Dump(JvPrintNone)+"]", fn)
+			}
+			return nil
+		})
+	case JvKindArray:
+		len := jv.Copy().ArrayLength()
+		for i := 0; i < len; i++ {
+			elem := jv.Copy().ArrayGet(i)
+			eachLeaf(elem, fmt.Sprintf("%s[%d]", path, i), fn)
+			elem.Free()
+		}
+	default:
+		fn(path, jv)
+	}
+}
+
+// preview returns jv as compact JSON, abbreviating arrays and objects and
+// truncating long values.
+//
+// Does not consume jv.
+func preview(jv *Jv) string {
+	switch jv.Kind() {
+	case JvKindObject:
+		return "{...}"
+	case JvKindArray:
+		return "[...]"
+	}
+
+	dump := []rune(jv.Copy().Dump(JvPrintNone))
+	if len(dump) > explainValueLen {
+		return string(dump[:explainValueLen]) + "..."
+	}
+	return string(dump)
+}
+
+// previewList joins the first few items, followed by "..." if there are
+// more.
+func previewList(items []string) string {
+	if len(items) > explainPreviewLen {
+		items = append(items[:explainPreviewLen:explainPreviewLen], "...")
+	}
+	return strings.Join(items, ", ")
+}
+
+func plural(n int, singular, plural string) string {
+	if n == 1 {
+		return "1 " + singular
+	}
+	return fmt.Sprintf("%d %s", n, plural)
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2018 Jimmy Zelinskie
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package jq_test
+
+import (
+	"testing"
+
+	"github.com/jzelinskie/faq/jq"
+)
+
+func TestJvExplain(t *testing.T) {
+	table := []struct {
+		testName string
+		input    string
+		output   string
+	}{
+		{"Object", `{"name": "jimmy", "age": 30, "address": {"city": "NYC", "zip": ["10001"]}, "tags": [], "active": true}`, "object(5 keys, depth 3): {name, age, address, ...}\n5 leaves"},
+		{"SmallObject", `{"a": 1}`, "object(1 key, depth 1): {a}\n1 leaf"},
+		{"EmptyObject", `{}`, "object(0 keys, depth 1): {}\n0 leaves"},
+		{"Array", `[1, "two", [3], {"four": 4}]`, "array(4 elements, depth 2): [1, \"two\", [...], ...]\n4 leaves"},
+		{"Number", `42`, "number: 42"},
+		{"Null", `null`, "null: null"},
+		{"LongString", `"abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyz"`, `string: "abcdefghijklmnopqrstuvwxyzabcdefghijklm...`},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			input := mustParse(t, tt.input)
+			defer input.Free()
+
+			if explanation := input.Explain(); explanation != tt.output {
+				t.Errorf("Explain() got:\n%s\nwant:\n%s", explanation, tt.output)
+			}
+		})
+	}
+}
+
+func TestJvExplainVerbose(t *testing.T) {
+	input := mustParse(t, `{"name": "jimmy", "address": {"zip": ["10001"]}, "my key": null, "empty": []}`)
+	defer input.Free()
+
+	want := `object(4 keys, depth 3): {name, address, my key, ...}
+3 leaves
+  .name: "jimmy"
+  .address.zip[0]: "10001"
+  ["my key"]: null`
+	if explanation := input.ExplainVerbose(); explanation != want {
+		t.Errorf("ExplainVerbose() got:\n%s\nwant:\n%s", explanation, want)
+	}
+
+	num := jq.JvFromFloat(1)
+	defer num.Free()
+	if explanation := num.ExplainVerbose(); explanation != "number: 1\n  .: 1" {
+		t.Errorf("ExplainVerbose() got:\n%s\nwant:\n%s", explanation, "number: 1\n  .: 1")
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2018 Jimmy Zelinskie
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package jq
+
+import (
+	flatbuffers "github.com/google/flatbuffers/go"
+)
+
+// ToFlatbuffer serializes jv into builder, leaving the FlatBuffers schema to
+// tableOffset: it is given the value to serialize, builds the table for it,
+// including any strings or vectors the table holds before starting it, and
+// returns the table's offset.
+//
+// For an array-typed jv, tableOffset is called with each element and the
+// offsets of their tables are put in a vector, whose offset is returned, so
+// that a JSON array of records becomes a vector of tables. For anything else,
+// the offset returned by tableOffset for jv is.
+//
+// tableOffset is given copies, which are freed once it returns.
+//
+// Does not consume the invocant.
+func (jv *Jv) ToFlatbuffer(builder *flatbuffers.Builder, tableOffset func(*Jv) flatbuffers.UOffsetT) flatbuffers.UOffsetT {
+	if jv.Kind() != JvKindArray {
+		value := jv.Copy()
+		defer value.Free()
+		return tableOffset(value)
+	}
+
+	// The tables must be built before the vector that refers to them.
+	length := jv.Copy().ArrayLength()
+	offsets := make([]flatbuffers.UOffsetT, length)
+	for i := range offsets {
+		elem := jv.Copy().ArrayGet(i)
+		offsets[i] = tableOffset(elem)
+		elem.Free()
+	}
+
+	builder.StartVector(flatbuffers.SizeUOffsetT, length, flatbuffers.SizeUOffsetT)
+	for i := length - 1; i >= 0; i-- {
+		builder.PrependUOffsetT(offsets[i])
+	}
+	return builder.EndVector(length)
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2018 Jimmy Zelinskie
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package jq_test
+
+import (
+	"testing"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+
+	"github.com/jzelinskie/faq/jq"
+)
+
+// The schema used by the tests, written by hand as flatc would generate it:
+//
+//	table User { name: string; age: int; }
+//	table Users { users: [User]; }
+const (
+	userNameSlot  = 0
+	userAgeSlot   = 1
+	usersListSlot = 0
+)
+
+// userOffset builds a User table from an object with name and age fields.
+func userOffset(builder *flatbuffers.Builder) func(*jq.Jv) flatbuffers.UOffsetT {
+	return func(jv *jq.Jv) flatbuffers.UOffsetT {
+		nameJv, _ := jv.TypedGet("name", jq.JvKindString)
+		name, _ := nameJv.StringValue()
+		nameJv.Free()
+		ageJv, _ := jv.TypedGet("age", jq.JvKindNumber)
+		age, _ := ageJv.ToFloat64()
+		ageJv.Free()
+
+		nameOffset := builder.CreateString(name)
+		builder.StartObject(2)
+		builder.PrependUOffsetTSlot(userNameSlot, nameOffset, 0)
+		builder.PrependInt32Slot(userAgeSlot, int32(age), 0)
+		return builder.EndObject()
+	}
+}
+
+// readUser returns the fields of the User table at pos in buf.
+func readUser(buf []byte, pos flatbuffers.UOffsetT) (string, int32) {
+	table := &flatbuffers.Table{Bytes: buf, Pos: pos}
+	name := table.String(table.Pos + flatbuffers.UOffsetT(table.Offset(flatbuffers.VOffsetT(4+2*userNameSlot))))
+	age := table.GetInt32Slot(flatbuffers.VOffsetT(4+2*userAgeSlot), 0)
+	return name, age
+}
+
+func TestJvToFlatbufferArray(t *testing.T) {
+	input := mustParse(t, `[{"name":"ada","age":36},{"name":"grace","age":85}]`)
+	defer input.Free()
+
+	builder := flatbuffers.NewBuilder(0)
+	users := input.ToFlatbuffer(builder, userOffset(builder))
+	builder.StartObject(1)
+	builder.PrependUOffsetTSlot(usersListSlot, users, 0)
+	builder.Finish(builder.EndObject())
+
+	buf := builder.FinishedBytes()
+	root := &flatbuffers.Table{Bytes: buf, Pos: flatbuffers.GetUOffsetT(buf)}
+	o := flatbuffers.UOffsetT(root.Offset(flatbuffers.VOffsetT(4 + 2*usersListSlot)))
+	if o == 0 {
+		t.Fatal("users field is missing")
+	}
+
+	want := []struct {
+		name string
+		age  int32
+	}{{"ada", 36}, {"grace", 85}}
+	if n := root.VectorLen(o); n != len(want) {
+		t.Fatalf("got %d users, want: %d", n, len(want))
+	}
+	vector := root.Vector(o)
+	for i, user := range want {
+		elem := vector + flatbuffers.UOffsetT(i*flatbuffers.SizeUOffsetT)
+		name, age := readUser(buf, root.Indirect(elem))
+		if name != user.name || age != user.age {
+			t.Errorf("user %d got: %s, %d, want: %s, %d", i, name, age, user.name, user.age)
+		}
+	}
+}
+
+func TestJvToFlatbufferObject(t *testing.T) {
+	input := mustParse(t, `{"name":"ada","age":36}`)
+	defer input.Free()
+
+	builder := flatbuffers.NewBuilder(0)
+	builder.Finish(input.ToFlatbuffer(builder, userOffset(builder)))
+
+	buf := builder.FinishedBytes()
+	if name, age := readUser(buf, flatbuffers.GetUOffsetT(buf)); name != "ada" || age != 36 {
+		t.Errorf("ToFlatbuffer() got: %s, %d, want: ada, 36", name, age)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2018 Jimmy Zelinskie
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+//go:build go1.23
+
+package jq
+
+import (
+	"errors"
+	"iter"
+)
+
+// errStopIteration stops ObjectForEach when the loop body of a range over
+// Iter or IterKV breaks.
+var errStopIteration = errors.New("stop iteration")
+
+// Iter returns an iterator over the elements of an array-typed jv or the values
+// of an object-typed jv, in order. Nothing is yielded for any other kind.
+//
+// Each value is freed once the loop body returns, so it must be Copy()'d to
+// be kept.
+//
+// Does not consume the invocant.
+func (jv *Jv) Iter() iter.Seq[*Jv] {
+	return func(yield func(*Jv) bool) {
+		for _, v := range jv.IterKV() {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// IterKV returns an iterator over the keys and values of an object-typed jv,
+// or the indexes and elements of an array-typed jv, in order. Keys are
+// string-typed and indexes are number-typed. Nothing is yielded for any other
+// kind.
+//
+// Each key and value is freed once the loop body returns, so they must be
+// Copy()'d to be kept.
+//
+// Does not consume the invocant.
+func (jv *Jv) IterKV() iter.Seq2[*Jv, *Jv] {
+	return func(yield func(*Jv, *Jv) bool) {
+		switch jv.Kind() {
+		case JvKindArray:
+			len := jv.Copy().ArrayLength()
+			for i := 0; i < len; i++ {
+				index := JvFromFloat(float64(i))
+				elem := jv.Copy().ArrayGet(i)
+				ok := yield(index, elem)
+				index.Free()
+				elem.Free()
+				if !ok {
+					return
+				}
+			}
+		case JvKindObject:
+			jv.ObjectForEach(func(key string, value *Jv) error {
+				keyJv := JvFromString(key)
+				defer keyJv.Free()
+				if !yield(keyJv, value) {
+					return errStopIteration
+				}
+				return nil
+			})
+		}
+	}
+}
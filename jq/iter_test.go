@@ -0,0 +1,94 @@
+// Copyright (c) 2018 Jimmy Zelinskie
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+//go:build go1.23
+
+package jq_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jzelinskie/faq/jq"
+)
+
+func TestJvIter(t *testing.T) {
+	table := []struct {
+		testName string
+		input    string
+		values   string
+		pairs    string
+	}{
+		{"Array", `[1, "two", [3]]`, `1 "two" [3]`, `0=1 1="two" 2=[3]`},
+		{"Object", `{"b": 1, "a": {"c": null}}`, `1 {"c":null}`, `"b"=1 "a"={"c":null}`},
+		{"Empty", `[]`, ``, ``},
+		{"Scalar", `"str"`, ``, ``},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			input := mustParse(t, tt.input)
+			defer input.Free()
+
+			var values []string
+			for v := range input.Iter() {
+				values = append(values, v.Copy().Dump(jq.JvPrintNone))
+			}
+			if got := strings.Join(values, " "); got != tt.values {
+				t.Errorf("Iter() yielded: %s, want: %s", got, tt.values)
+			}
+
+			var pairs []string
+			for k, v := range input.IterKV() {
+				pairs = append(pairs, k.Copy().Dump(jq.JvPrintNone)+"="+v.Copy().Dump(jq.JvPrintNone))
+			}
+			if got := strings.Join(pairs, " "); got != tt.pairs {
+				t.Errorf("IterKV() yielded: %s, want: %s", got, tt.pairs)
+			}
+		})
+	}
+}
+
+func TestJvIterBreak(t *testing.T) {
+	for _, input := range []string{`[1, 2, 3]`, `{"a": 1, "b": 2, "c": 3}`} {
+		jv := mustParse(t, input)
+
+		n := 0
+		for range jv.Iter() {
+			n++
+			if n == 2 {
+				break
+			}
+		}
+		if n != 2 {
+			t.Errorf("Iter() over %s continued after break", input)
+		}
+
+		n = 0
+		for range jv.IterKV() {
+			n++
+			break
+		}
+		if n != 1 {
+			t.Errorf("IterKV() over %s continued after break", input)
+		}
+		jv.Free()
+	}
+}
@@ -131,11 +131,19 @@ var globalErrorChannels = errorLookupState{
 	channels: make(map[uint64]chan<- error),
 }
 
+// ErrorHandler is called, if set, with each error reported by libjq, such as
+// every error found when compiling a program, before it is returned. It may be
+// called from any goroutine.
+var ErrorHandler func(err error)
+
 //export goLibjqErrorHandler
 func goLibjqErrorHandler(id uint64, jv C.jv) {
 	ch := globalErrorChannels.Get(id)
 
 	err := _ConvertError(jv)
+	if ErrorHandler != nil {
+		ErrorHandler(err)
+	}
 	ch <- err
 }
 
@@ -22,6 +22,7 @@
 package jq_test
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 
@@ -145,6 +146,26 @@ func TestCompileError(t *testing.T) {
 	t.Fatal("No error containing the program source found")
 }
 
+func TestErrorHandler(t *testing.T) {
+	var handled []error
+	jq.ErrorHandler = func(err error) { handled = append(handled, err) }
+	defer func() { jq.ErrorHandler = nil }()
+
+	state, err := jq.New()
+	if err != nil {
+		t.Fatalf("Error initializing jq_state: %v", err)
+	}
+	defer state.Close()
+
+	errs := state.Compile("a b", jq.JvArray())
+	if len(handled) == 0 {
+		t.Fatal("ErrorHandler was not called")
+	}
+	if len(handled) != len(errs) {
+		t.Errorf("ErrorHandler got %d errors, Compile returned %d", len(handled), len(errs))
+	}
+}
+
 func TestCompileGood(t *testing.T) {
 	state, err := jq.New()
 
@@ -242,3 +263,42 @@ func TestJqRuntimeError(t *testing.T) {
 		t.Errorf("Got %d errors (%#v), expected %d", l, errors, 1)
 	}
 }
+
+func TestVersion(t *testing.T) {
+	version := jq.Version()
+	if version == "" {
+		t.Fatal("Version() returned an empty string")
+	}
+	if again := jq.Version(); again != version {
+		t.Errorf("Version() got: %s, then: %s", version, again)
+	}
+}
+
+func TestFreeVariables(t *testing.T) {
+	table := []struct {
+		testName string
+		program  string
+		want     []string
+		err      bool
+	}{
+		{"None", ".a | . + 1", nil, false},
+		{"Referenced", "$foo | $bar + $baz", []string{"foo", "bar", "baz"}, false},
+		{"Repeated", "$foo + $bar + $foo", []string{"foo", "bar"}, false},
+		{"Bound", ". as $x | $x + $y", []string{"y"}, false},
+		{"FunctionParameter", "def f($a): $a + $b; f(1)", []string{"b"}, false},
+		{"Builtin", "$ENV | $__loc__", nil, false},
+		{"SyntaxError", "$foo | .a[", nil, true},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			got, err := jq.FreeVariables(tt.program)
+			if (err != nil) != tt.err {
+				t.Fatalf("FreeVariables() got error: %v, want error: %t", err, tt.err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FreeVariables() got: %q, want: %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -31,9 +31,18 @@ package jq
 */
 import "C"
 import (
+	"container/heap"
 	"errors"
 	"fmt"
+	"math"
+	"os"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
 	"unsafe"
 )
 
@@ -108,6 +117,34 @@ func (kind JvKind) String() string {
 	}
 }
 
+// KindError is returned when an operation is attempted on a Jv whose kind
+// does not support it.
+type KindError struct {
+	// Op is the name of the method that was called.
+	Op string
+
+	// Kind is the kind of the Jv the method was called on.
+	Kind JvKind
+
+	// Field, when set, is the key of the field whose kind was wrong, in which
+	// case Kind is the kind of the field, or JvKindInvalid if it is missing.
+	Field string
+
+	// Expected is the kind that Field was expected to have.
+	Expected JvKind
+}
+
+func (e *KindError) Error() string {
+	switch {
+	case e.Field == "":
+		return fmt.Sprintf("cannot call %s on jv of type %s", e.Op, e.Kind)
+	case e.Kind == JvKindInvalid:
+		return fmt.Sprintf("expected field '%s' to be %s, but it is missing", e.Field, e.Expected)
+	default:
+		return fmt.Sprintf("expected field '%s' to be %s, got %s", e.Field, e.Expected, e.Kind)
+	}
+}
+
 // JvNull returns a value representing a JSON null
 func JvNull() *Jv {
 	return &Jv{C.jv_null()}
@@ -135,6 +172,64 @@ func JvFromString(str string) *Jv {
 	return &Jv{C.jv_string_sized(cs, C.int(len(str)))}
 }
 
+// JvFromStringSlice returns a new jv array-typed value containing each of the
+// given strings.
+func JvFromStringSlice(parts []string) *Jv {
+	ary := JvArray()
+	for _, part := range parts {
+		ary = ary.ArrayAppend(JvFromString(part))
+	}
+	return ary
+}
+
+// JvFromEnv returns a new jv object-typed value mapping the name of each
+// environment variable to its value, like jq's `env`.
+func JvFromEnv() *Jv {
+	return jvFromEnviron(os.Environ())
+}
+
+// jvFromEnviron returns a new jv object-typed value mapping the names of the
+// KEY=VALUE pairs in environ to their values.
+func jvFromEnviron(environ []string) *Jv {
+	obj := JvObject()
+	for _, kv := range environ {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		obj = obj.ObjectSet(JvFromString(parts[0]), JvFromString(parts[1]))
+	}
+	return obj
+}
+
+// JvFromCodepoints returns a new jv string-typed value built from an array of
+// Unicode codepoints, like jq's `implode`.
+//
+// Returns a *KindError if codepoints is not an array, or an error if any
+// element is not a valid codepoint.
+//
+// Consumes codepoints.
+func JvFromCodepoints(codepoints *Jv) (*Jv, error) {
+	defer codepoints.Free()
+
+	if codepoints.Kind() != JvKindArray {
+		return nil, &KindError{Op: "JvFromCodepoints", Kind: codepoints.Kind()}
+	}
+
+	len := codepoints.Copy().ArrayLength()
+	runes := make([]rune, len)
+	for i := 0; i < len; i++ {
+		elem := codepoints.Copy().ArrayGet(i)
+		n, err := elem.ToFloat64()
+		elem.Free()
+		if err != nil || n != float64(rune(n)) || !utf8.ValidRune(rune(n)) {
+			return nil, fmt.Errorf("element %d is not a valid codepoint", i)
+		}
+		runes[i] = rune(n)
+	}
+	return JvFromString(string(runes)), nil
+}
+
 // JvFromFloat returns a new jv number-typed value containing the given float
 // value.
 func JvFromFloat(n float64) *Jv {
@@ -261,7 +356,12 @@ func JvFromJSONString(str string) (*Jv, error) {
 // JvFromJSONBytes takes a utf-8 byte sequence containing JSON and returns the
 // jv representation of it.
 func JvFromJSONBytes(b []byte) (*Jv, error) {
-	jv := C.jv_parse((*C.char)(unsafe.Pointer(&b[0])))
+	if len(b) == 0 {
+		return JvFromJSONString("")
+	}
+
+	// b isn't necessarily NUL-terminated, so its length must be given.
+	jv := C.jv_parse_sized((*C.char)(unsafe.Pointer(&b[0])), C.int(len(b)))
 
 	if C.jv_is_valid(jv) == 0 {
 		return nil, _ConvertError(jv)
@@ -311,13 +411,15 @@ func (jv *Jv) IsValid() bool {
 // Consumes the invocant.
 func (jv *Jv) GetInvalidMessageAsString() (string, bool) {
 	msg := C.jv_invalid_get_msg(jv.jv)
-	defer C.jv_free(msg)
 
 	if C.jv_get_kind(msg) == C.JV_KIND_NULL {
+		C.jv_free(msg)
 		return "", false
 	} else if C.jv_get_kind(msg) != C.JV_KIND_STRING {
+		// jv_dump_string consumes msg, so only the dumped string must be freed.
 		msg = C.jv_dump_string(msg, 0)
 	}
+	defer C.jv_free(msg)
 	return C.GoString(C.jv_string_value(msg)), true
 }
 
@@ -333,13 +435,11 @@ func (jv *Jv) _string() string {
 	return C.GoString(cs)
 }
 
-// If jv is a string, return its value. Will not stringify other types
+// StringValue returns the value of a string-typed jv. Will not stringify
+// other types; use CompactJSON for that.
 //
 // Does not consume the invocant.
-func (jv *Jv) String() (string, error) {
-	// Doing this might be a bad idea as it means we almost implement the Stringer
-	// interface but not quite (cos the error type)
-
+func (jv *Jv) StringValue() (string, error) {
 	// If we don't do this check JV will assert
 	if C.jv_get_kind(jv.jv) != C.JV_KIND_STRING {
 		return "", fmt.Errorf("Cannot return String for jv of type %s", jv.Kind())
@@ -348,6 +448,368 @@ func (jv *Jv) String() (string, error) {
 	return jv._string(), nil
 }
 
+// String is the same as StringValue.
+//
+// Deprecated: String almost implements fmt.Stringer, but not quite because of
+// its error, so Jvs can't be printed with fmt's %v. Use StringValue to get the
+// value of a string-typed jv, which is a drop-in replacement, or CompactJSON
+// to get the JSON of a jv of any kind. String will be changed to implement
+// fmt.Stringer by returning CompactJSON in a future release.
+//
+// Does not consume the invocant.
+func (jv *Jv) String() (string, error) {
+	return jv.StringValue()
+}
+
+// CompactJSON returns jv serialized as JSON without any whitespace, such as
+// `{"a":[1,"x"]}`, whatever its kind.
+//
+// Does not consume the invocant.
+func (jv *Jv) CompactJSON() string {
+	return jv.Copy().Dump(JvPrintNone)
+}
+
+// GoString implements fmt.GoStringer, so that Jvs are printed as their JSON
+// with fmt's %#v, rather than as a pointer.
+//
+// Does not consume the invocant.
+func (jv *Jv) GoString() string {
+	return jv.CompactJSON()
+}
+
+// ASCIIDowncase returns a new string-typed jv with jv converted to lower
+// case.
+//
+// Unlike jq's `ascii_downcase`, which only changes the letters A-Z, this uses
+// strings.ToLower and so also converts non-ASCII letters: "ÀB" becomes "àb"
+// rather than "Àb".
+//
+// Returns a *KindError if jv is not a string.
+//
+// Does not consume the invocant.
+func (jv *Jv) ASCIIDowncase() (*Jv, error) {
+	str, err := jv.StringValue()
+	if err != nil {
+		return nil, &KindError{Op: "ASCIIDowncase", Kind: jv.Kind()}
+	}
+	return JvFromString(strings.ToLower(str)), nil
+}
+
+// ASCIIUpcase returns a new string-typed jv with jv converted to upper case.
+//
+// Unlike jq's `ascii_upcase`, which only changes the letters a-z, this uses
+// strings.ToUpper and so also converts non-ASCII letters: "àb" becomes "ÀB"
+// rather than "àB".
+//
+// Returns a *KindError if jv is not a string.
+//
+// Does not consume the invocant.
+func (jv *Jv) ASCIIUpcase() (*Jv, error) {
+	str, err := jv.StringValue()
+	if err != nil {
+		return nil, &KindError{Op: "ASCIIUpcase", Kind: jv.Kind()}
+	}
+	return JvFromString(strings.ToUpper(str)), nil
+}
+
+// Ltrimstr returns a new string-typed jv with prefix removed from the start of
+// jv, matching jq's `ltrimstr`. If jv does not start with prefix it is
+// returned unchanged.
+//
+// Returns a *KindError if jv is not a string.
+//
+// Does not consume the invocant.
+func (jv *Jv) Ltrimstr(prefix string) (*Jv, error) {
+	str, err := jv.StringValue()
+	if err != nil {
+		return nil, &KindError{Op: "Ltrimstr", Kind: jv.Kind()}
+	}
+	return JvFromString(strings.TrimPrefix(str, prefix)), nil
+}
+
+// Rtrimstr returns a new string-typed jv with suffix removed from the end of
+// jv, matching jq's `rtrimstr`. If jv does not end with suffix it is returned
+// unchanged.
+//
+// Returns a *KindError if jv is not a string.
+//
+// Does not consume the invocant.
+func (jv *Jv) Rtrimstr(suffix string) (*Jv, error) {
+	str, err := jv.StringValue()
+	if err != nil {
+		return nil, &KindError{Op: "Rtrimstr", Kind: jv.Kind()}
+	}
+	return JvFromString(strings.TrimSuffix(str, suffix)), nil
+}
+
+// Ltrim returns a new string-typed jv with leading whitespace removed.
+//
+// Returns a *KindError if jv is not a string.
+//
+// Does not consume the invocant.
+func (jv *Jv) Ltrim() (*Jv, error) {
+	str, err := jv.StringValue()
+	if err != nil {
+		return nil, &KindError{Op: "Ltrim", Kind: jv.Kind()}
+	}
+	return JvFromString(strings.TrimLeftFunc(str, unicode.IsSpace)), nil
+}
+
+// Rtrim returns a new string-typed jv with trailing whitespace removed.
+//
+// Returns a *KindError if jv is not a string.
+//
+// Does not consume the invocant.
+func (jv *Jv) Rtrim() (*Jv, error) {
+	str, err := jv.StringValue()
+	if err != nil {
+		return nil, &KindError{Op: "Rtrim", Kind: jv.Kind()}
+	}
+	return JvFromString(strings.TrimRightFunc(str, unicode.IsSpace)), nil
+}
+
+// Split returns a new array-typed jv of the substrings of jv separated by sep,
+// matching jq's `split(sep)`.
+//
+// Returns a *KindError if jv is not a string.
+//
+// Does not consume the invocant.
+func (jv *Jv) Split(sep string) (*Jv, error) {
+	str, err := jv.StringValue()
+	if err != nil {
+		return nil, &KindError{Op: "Split", Kind: jv.Kind()}
+	}
+	if str == "" {
+		// jq splits the empty string into no parts rather than one empty part.
+		return JvArray(), nil
+	}
+	return JvFromStringSlice(strings.Split(str, sep)), nil
+}
+
+// Join returns a new string-typed jv of the elements of an array-typed jv
+// separated by sep, matching jq's `join(sep)` for arrays of strings.
+//
+// Returns a *KindError if jv is not an array, or an error if any element is
+// not a string.
+//
+// Does not consume the invocant.
+func (jv *Jv) Join(sep string) (*Jv, error) {
+	if jv.Kind() != JvKindArray {
+		return nil, &KindError{Op: "Join", Kind: jv.Kind()}
+	}
+
+	len := jv.Copy().ArrayLength()
+	parts := make([]string, len)
+	for i := 0; i < len; i++ {
+		elem := jv.Copy().ArrayGet(i)
+		kind := elem.Kind()
+		str, err := elem.StringValue()
+		elem.Free()
+		if err != nil {
+			return nil, fmt.Errorf("cannot join element %d of type %s", i, kind)
+		}
+		parts[i] = str
+	}
+	return JvFromString(strings.Join(parts, sep)), nil
+}
+
+// Flatten returns a new array-typed jv with the elements of any arrays nested
+// within jv replaced by their own elements, like jq's `flatten(depth)`. Only
+// depth levels of nesting are removed; a depth of -1 removes all of them and
+// a depth of 0 returns a copy of jv.
+//
+// Returns a *KindError if jv is not an array.
+//
+// Does not consume the invocant.
+func (jv *Jv) Flatten(depth int) (*Jv, error) {
+	if jv.Kind() != JvKindArray {
+		return nil, &KindError{Op: "Flatten", Kind: jv.Kind()}
+	}
+	if depth < -1 {
+		return nil, fmt.Errorf("flatten depth must not be negative, got %d", depth)
+	}
+	return flatten(JvArray(), jv, depth), nil
+}
+
+// flatten appends the elements of the array-typed jv to result, descending
+// into nested arrays up to depth levels.
+//
+// Consumes result. Does not consume jv.
+func flatten(result, jv *Jv, depth int) *Jv {
+	len := jv.Copy().ArrayLength()
+	for i := 0; i < len; i++ {
+		elem := jv.Copy().ArrayGet(i)
+		if depth != 0 && elem.Kind() == JvKindArray {
+			result = flatten(result, elem, depth-1)
+			elem.Free()
+			continue
+		}
+		result = result.ArrayAppend(elem)
+	}
+	return result
+}
+
+// Explode returns a new array-typed jv of the Unicode codepoints of a
+// string-typed jv, like jq's `explode`.
+//
+// Returns a *KindError if jv is not a string.
+//
+// Does not consume the invocant.
+func (jv *Jv) Explode() (*Jv, error) {
+	str, err := jv.StringValue()
+	if err != nil {
+		return nil, &KindError{Op: "Explode", Kind: jv.Kind()}
+	}
+
+	codepoints := JvArray()
+	for _, r := range str {
+		codepoints = codepoints.ArrayAppend(JvFromFloat(float64(r)))
+	}
+	return codepoints, nil
+}
+
+// Tojson returns a new string-typed jv containing jv serialized as JSON, like
+// jq's `tojson`.
+//
+// Returns a *KindError if jv is invalid.
+//
+// Does not consume the invocant.
+func (jv *Jv) Tojson() (*Jv, error) {
+	if jv.Kind() == JvKindInvalid {
+		return nil, &KindError{Op: "Tojson", Kind: jv.Kind()}
+	}
+	return JvFromString(jv.Copy().Dump(JvPrintNone)), nil
+}
+
+// Fromjson parses the JSON text in a string-typed jv, like jq's `fromjson`.
+//
+// Returns a *KindError if jv is not a string, or an error if it does not
+// contain valid JSON.
+//
+// Does not consume the invocant.
+func (jv *Jv) Fromjson() (*Jv, error) {
+	str, err := jv.StringValue()
+	if err != nil {
+		return nil, &KindError{Op: "Fromjson", Kind: jv.Kind()}
+	}
+	return JvFromJSONString(str)
+}
+
+// ToFloat64 returns the value of a number-typed jv.
+//
+// Returns a *KindError if jv is not a number.
+//
+// Does not consume the invocant.
+func (jv *Jv) ToFloat64() (float64, error) {
+	if jv.Kind() != JvKindNumber {
+		return 0, &KindError{Op: "ToFloat64", Kind: jv.Kind()}
+	}
+	return float64(C.jv_number_value(jv.jv)), nil
+}
+
+// NumberInRange reports whether a number-typed jv is within the inclusive
+// range [min, max].
+//
+// Returns a *KindError if jv is not a number.
+//
+// Does not consume the invocant.
+func (jv *Jv) NumberInRange(min, max float64) (bool, error) {
+	n, err := jv.ToFloat64()
+	if err != nil {
+		return false, &KindError{Op: "NumberInRange", Kind: jv.Kind()}
+	}
+	return n >= min && n <= max, nil
+}
+
+// NumberClamp returns a new number-typed jv holding the value of jv limited to
+// the inclusive range [min, max]. If jv is not a number, a copy of it is
+// returned unchanged.
+//
+// Does not consume the invocant.
+func (jv *Jv) NumberClamp(min, max float64) *Jv {
+	n, err := jv.ToFloat64()
+	if err != nil {
+		return jv.Copy()
+	}
+	if n < min {
+		n = min
+	} else if n > max {
+		n = max
+	}
+	return JvFromFloat(n)
+}
+
+// Normalize returns a new jv holding the value of jv with every number that is
+// NaN or infinite replaced by null, at any depth. jq arithmetic can produce
+// these numbers, and although Dump prints them as null, they are returned
+// as-is by ToGoVal and can't be marshaled by encoding/json.
+//
+// Does not consume the invocant.
+func (jv *Jv) Normalize() *Jv {
+	switch jv.Kind() {
+	case JvKindNumber:
+		if n, _ := jv.ToFloat64(); math.IsNaN(n) || math.IsInf(n, 0) {
+			return JvNull()
+		}
+		return jv.Copy()
+	case JvKindArray:
+		result := JvArray()
+		len := jv.Copy().ArrayLength()
+		for i := 0; i < len; i++ {
+			elem := jv.Copy().ArrayGet(i)
+			result = result.ArrayAppend(elem.Normalize())
+			elem.Free()
+		}
+		return result
+	case JvKindObject:
+		result := JvObject()
+		jv.ObjectForEach(func(key string, value *Jv) error {
+			result = result.ObjectSet(JvFromString(key), value.Normalize())
+			return nil
+		})
+		return result
+	default:
+		return jv.Copy()
+	}
+}
+
+// ErrorIfNull returns a copy of jv, or an error with the message msg if jv is
+// null or invalid, like jq's `if . == null then error(msg) else . end`.
+//
+// Does not consume the invocant.
+func (jv *Jv) ErrorIfNull(msg string) (*Jv, error) {
+	if !jv.IsValid() || jv.Kind() == JvKindNull {
+		return nil, errors.New(msg)
+	}
+	return jv.Copy(), nil
+}
+
+// ErrorIfEmpty is like ErrorIfNull, but also returns an error if jv is an
+// empty array, object or string.
+//
+// Does not consume the invocant.
+func (jv *Jv) ErrorIfEmpty(msg string) (*Jv, error) {
+	if !jv.IsValid() {
+		return nil, errors.New(msg)
+	}
+
+	empty := false
+	switch jv.Kind() {
+	case JvKindNull:
+		empty = true
+	case JvKindArray:
+		empty = jv.Copy().ArrayLength() == 0
+	case JvKindObject:
+		empty = C.jv_object_length(jv.Copy().jv) == 0
+	case JvKindString:
+		empty = C.jv_string_length_bytes(jv.Copy().jv) == 0
+	}
+	if empty {
+		return nil, errors.New(msg)
+	}
+	return jv.Copy(), nil
+}
+
 // ToGoVal converts a jv into it's closest Go approximation
 //
 // Does not consume the invocant.
@@ -393,6 +855,160 @@ func (jv *Jv) ToGoVal() interface{} {
 	}
 }
 
+// ToMap converts an object-typed jv into a Go map. Values are converted with
+// ToGoVal.
+//
+// Returns a *KindError if jv is not an object.
+//
+// Does not consume the invocant.
+func (jv *Jv) ToMap() (map[string]interface{}, error) {
+	if jv.Kind() != JvKindObject {
+		return nil, &KindError{Op: "ToMap", Kind: jv.Kind()}
+	}
+
+	obj := make(map[string]interface{})
+	for iter := C.jv_object_iter(jv.jv); C.jv_object_iter_valid(jv.jv, iter) != 0; iter = C.jv_object_iter_next(jv.jv, iter) {
+		k := Jv{C.jv_object_iter_key(jv.jv, iter)}
+		v := Jv{C.jv_object_iter_value(jv.jv, iter)}
+		obj[k._string()] = v.ToGoVal()
+		k.Free()
+		v.Free()
+	}
+	return obj, nil
+}
+
+// ToSlice converts an array-typed jv into a Go slice. Elements are converted
+// with ToGoVal.
+//
+// Returns a *KindError if jv is not an array.
+//
+// Does not consume the invocant.
+func (jv *Jv) ToSlice() ([]interface{}, error) {
+	if jv.Kind() != JvKindArray {
+		return nil, &KindError{Op: "ToSlice", Kind: jv.Kind()}
+	}
+
+	len := jv.Copy().ArrayLength()
+	ary := make([]interface{}, len)
+	for i := 0; i < len; i++ {
+		v := jv.Copy().ArrayGet(i)
+		ary[i] = v.ToGoVal()
+		v.Free()
+	}
+	return ary, nil
+}
+
+// Chan returns a channel with a buffer of bufSize that yields each element of
+// an array-typed jv, converted with ToGoVal, and is then closed. If jv is not
+// an array, the channel is closed without yielding anything.
+//
+// The elements are converted before Chan returns, so jv may be freed as soon
+// as it does.
+//
+// Does not consume the invocant.
+func (jv *Jv) Chan(bufSize int) <-chan interface{} {
+	elems, _ := jv.ToSlice()
+
+	ch := make(chan interface{}, bufSize)
+	go func() {
+		defer close(ch)
+		for _, elem := range elems {
+			ch <- elem
+		}
+	}()
+	return ch
+}
+
+// JvFromChan returns a new array-typed jv holding every value received from ch
+// until it is closed, each converted with JvFromInterface.
+//
+// Returns the first error from converting a value. ch is drained even after
+// an error so that its senders are not left blocked.
+func JvFromChan(ch <-chan interface{}) (*Jv, error) {
+	ary := JvArray()
+	var err error
+	for value := range ch {
+		if err != nil {
+			continue
+		}
+
+		var elem *Jv
+		if elem, err = JvFromInterface(value); err != nil {
+			ary.Free()
+			continue
+		}
+		ary = ary.ArrayAppend(elem)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return ary, nil
+}
+
+// SyncMap returns a sync.Map holding each key of an object-typed jv as a
+// string and its value as a *Jv, or nil if jv is not an object.
+//
+// The values held by the map are references to the values within jv. They
+// must not be freed while the map is in use, and the caller is responsible for
+// freeing them once it is not. The map is safe for concurrent use, but as
+// libjq's reference counting is not, the values should only be read
+// concurrently and not copied or freed.
+//
+// Does not consume the invocant.
+func (jv *Jv) SyncMap() *sync.Map {
+	if jv.Kind() != JvKindObject {
+		return nil
+	}
+
+	m := new(sync.Map)
+	jv.ObjectForEach(func(key string, value *Jv) error {
+		m.Store(key, &Jv{value.Copy().jv})
+		return nil
+	})
+	return m
+}
+
+// JvFromSyncMap returns a new object-typed jv holding the entries of m, as
+// produced by SyncMap. The keys are added in lexicographic order.
+//
+// Returns an error if a key is not a string or a value is not a *Jv.
+//
+// Does not consume the values held by m.
+func JvFromSyncMap(m *sync.Map) (*Jv, error) {
+	entries := make(map[string]*Jv)
+	var err error
+	m.Range(func(key, value interface{}) bool {
+		k, ok := key.(string)
+		if !ok {
+			err = fmt.Errorf("key %v is of type %T, not string", key, key)
+			return false
+		}
+		v, ok := value.(*Jv)
+		if !ok {
+			err = fmt.Errorf("value of key %s is of type %T, not *Jv", k, value)
+			return false
+		}
+		entries[k] = v
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	obj := JvObject()
+	for _, key := range keys {
+		obj = obj.ObjectSet(JvFromString(key), entries[key].Copy())
+	}
+	return obj, nil
+}
+
 // JvPrintFlags represents the type of flags used for configuring how Jvs are
 // printed.
 type JvPrintFlags int
@@ -444,6 +1060,29 @@ func (jv *Jv) Dump(flags JvPrintFlags) string {
 	return jvStr._string()
 }
 
+// MarshalJSON implements json.Marshaler so that a *Jv can be embedded in
+// values passed to json.Marshal. Invalid values are marshalled as null.
+//
+// Does not consume the invocant.
+func (jv *Jv) MarshalJSON() ([]byte, error) {
+	if !jv.IsValid() {
+		return []byte("null"), nil
+	}
+	return []byte(jv.Copy().Dump(JvPrintNone)), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, replacing the value held by jv
+// with the one parsed from b. The previous value is freed.
+func (jv *Jv) UnmarshalJSON(b []byte) error {
+	parsed, err := JvFromJSONBytes(b)
+	if err != nil {
+		return err
+	}
+	jv.Free()
+	jv.jv = parsed.jv
+	return nil
+}
+
 // JvArray creates a new, empty array-typed JV
 func JvArray() *Jv {
 	return &Jv{C.jv_array()}
@@ -477,6 +1116,16 @@ func (jv *Jv) ArrayGet(idx int) *Jv {
 	return &Jv{C.jv_array_get(jv.jv, C.int(idx))}
 }
 
+// ArraySet sets the element at the given array index to val, padding the
+// array with nulls if the index is past its end.
+//
+// `idx` cannot be negative.
+//
+// Consumes the invocant and val
+func (jv *Jv) ArraySet(idx int, val *Jv) *Jv {
+	return &Jv{C.jv_array_set(jv.jv, C.int(idx), val.jv)}
+}
+
 // JvObject allocates a new Jv of type object.
 func JvObject() *Jv {
 	return &Jv{C.jv_object()}
@@ -490,3 +1139,955 @@ func JvObject() *Jv {
 func (jv *Jv) ObjectSet(key *Jv, val *Jv) *Jv {
 	return &Jv{C.jv_object_set(jv.jv, key.jv, val.jv)}
 }
+
+// ObjectGet returns the value of the object under the given key.
+//
+// If the key is missing it will return an Invalid Jv object (with no error
+// message set).
+//
+// Consumes invocant and key
+func (jv *Jv) ObjectGet(key *Jv) *Jv {
+	return &Jv{C.jv_object_get(jv.jv, key.jv)}
+}
+
+// ObjectForEach calls fn with each key and value of an object-typed jv in
+// insertion order, stopping at the first error returned by fn.
+//
+// The value passed to fn is freed once fn returns, so fn must Copy() it to
+// keep it.
+//
+// Returns a *KindError if jv is not an object.
+//
+// Does not consume the invocant.
+func (jv *Jv) ObjectForEach(fn func(key string, value *Jv) error) error {
+	if jv.Kind() != JvKindObject {
+		return &KindError{Op: "ObjectForEach", Kind: jv.Kind()}
+	}
+
+	for iter := C.jv_object_iter(jv.jv); C.jv_object_iter_valid(jv.jv, iter) != 0; iter = C.jv_object_iter_next(jv.jv, iter) {
+		k := Jv{C.jv_object_iter_key(jv.jv, iter)}
+		v := &Jv{C.jv_object_iter_value(jv.jv, iter)}
+		key := k._string()
+		k.Free()
+
+		err := fn(key, v)
+		v.Free()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TypedGet returns the value of the field key of an object-typed jv, checking
+// that it is of the given kind.
+//
+// Returns a *KindError if jv is not an object, or one with Field set if the
+// field is missing or is of another kind. JvKindTrue and JvKindFalse both
+// accept either boolean.
+//
+// Does not consume the invocant.
+func (jv *Jv) TypedGet(key string, kind JvKind) (*Jv, error) {
+	if jv.Kind() != JvKindObject {
+		return nil, &KindError{Op: "TypedGet", Kind: jv.Kind()}
+	}
+
+	keyJv := JvFromString(key)
+	defer keyJv.Free()
+	if C.jv_object_has(jv.Copy().jv, keyJv.Copy().jv) == 0 {
+		return nil, &KindError{Op: "TypedGet", Kind: JvKindInvalid, Field: key, Expected: kind}
+	}
+
+	value := &Jv{C.jv_object_get(jv.Copy().jv, keyJv.Copy().jv)}
+	isBool := func(k JvKind) bool { return k == JvKindTrue || k == JvKindFalse }
+	if value.Kind() != kind && !(isBool(value.Kind()) && isBool(kind)) {
+		err := &KindError{Op: "TypedGet", Kind: value.Kind(), Field: key, Expected: kind}
+		value.Free()
+		return nil, err
+	}
+	return value, nil
+}
+
+// SortedKeys returns the keys of an object-typed jv in lexicographic order.
+//
+// Returns a *KindError if jv is not an object.
+//
+// Does not consume the invocant.
+func (jv *Jv) SortedKeys() ([]string, error) {
+	if jv.Kind() != JvKindObject {
+		return nil, &KindError{Op: "SortedKeys", Kind: jv.Kind()}
+	}
+
+	var keys []string
+	jv.ObjectForEach(func(key string, _ *Jv) error {
+		keys = append(keys, key)
+		return nil
+	})
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// arrayElems returns the elements of an array-typed jv, which the caller must
+// free.
+//
+// Returns a *KindError for op if jv is not an array.
+//
+// Does not consume the invocant.
+func (jv *Jv) arrayElems(op string) ([]*Jv, error) {
+	if jv.Kind() != JvKindArray {
+		return nil, &KindError{Op: op, Kind: jv.Kind()}
+	}
+
+	elems := make([]*Jv, jv.Copy().ArrayLength())
+	for i := range elems {
+		elems[i] = jv.Copy().ArrayGet(i)
+	}
+	return elems, nil
+}
+
+// jvFromSlice returns a new array-typed jv of elems.
+//
+// Consumes elems.
+func jvFromSlice(elems []*Jv) *Jv {
+	ary := JvArray()
+	for _, elem := range elems {
+		ary = ary.ArrayAppend(elem)
+	}
+	return ary
+}
+
+// StableSort returns a new array-typed jv of the elements of jv sorted by
+// less, keeping equal elements in their original order. less is given copies
+// of the elements, which are freed once it returns.
+//
+// Returns a *KindError if jv is not an array, or an error if less panics.
+//
+// Does not consume the invocant.
+func (jv *Jv) StableSort(less func(a, b *Jv) bool) (sorted *Jv, err error) {
+	elems, err := jv.arrayElems("StableSort")
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			freeAll(elems)
+			sorted, err = nil, fmt.Errorf("StableSort: less panicked: %v", r)
+		}
+	}()
+
+	sort.SliceStable(elems, func(i, j int) bool {
+		return callLess(less, elems[i], elems[j])
+	})
+	return jvFromSlice(elems), nil
+}
+
+// TopN returns a new array-typed jv of the n largest elements of jv
+// according to less, largest first. If jv has fewer than n elements, all of
+// them are returned. less is given copies of the elements, which are freed
+// once it returns.
+//
+// Returns a *KindError if jv is not an array, or an error if n is negative or
+// less panics.
+//
+// Does not consume the invocant.
+func (jv *Jv) TopN(n int, less func(a, b *Jv) bool) (*Jv, error) {
+	return jv.selectN("TopN", n, less)
+}
+
+// BottomN returns a new array-typed jv of the n smallest elements of jv
+// according to less, smallest first. It is otherwise the same as TopN.
+//
+// Does not consume the invocant.
+func (jv *Jv) BottomN(n int, less func(a, b *Jv) bool) (*Jv, error) {
+	return jv.selectN("BottomN", n, func(a, b *Jv) bool { return less(b, a) })
+}
+
+// selectN returns the n largest elements of jv according to less, largest
+// first, keeping only n elements at a time in a heap.
+//
+// Does not consume the invocant.
+func (jv *Jv) selectN(op string, n int, less func(a, b *Jv) bool) (selected *Jv, err error) {
+	if jv.Kind() != JvKindArray {
+		return nil, &KindError{Op: op, Kind: jv.Kind()}
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("%s: n must not be negative, got %d", op, n)
+	}
+
+	h := &jvHeap{less: less}
+	var elems []*Jv
+
+	// pending is the element being compared against the root of a full heap,
+	// which is owned by neither the heap nor elems while less runs.
+	var pending *Jv
+	defer func() {
+		if r := recover(); r != nil {
+			if pending != nil {
+				pending.Free()
+			}
+			freeAll(h.elems)
+			for _, elem := range elems {
+				if elem != nil {
+					elem.Free()
+				}
+			}
+			selected, err = nil, fmt.Errorf("%s: less panicked: %v", op, r)
+		}
+	}()
+
+	length := jv.Copy().ArrayLength()
+	for i := 0; i < length && n > 0; i++ {
+		elem := jv.Copy().ArrayGet(i)
+		if h.Len() < n {
+			heap.Push(h, elem)
+			continue
+		}
+
+		pending = elem
+		replace := callLess(less, h.elems[0], elem)
+		pending = nil
+		if replace {
+			h.elems[0].Free()
+			h.elems[0] = elem
+			heap.Fix(h, 0)
+		} else {
+			elem.Free()
+		}
+	}
+
+	// Popping the heap yields the smallest of the elements first.
+	elems = make([]*Jv, h.Len())
+	for i := len(elems) - 1; i >= 0; i-- {
+		elems[i] = heap.Pop(h).(*Jv)
+	}
+	return jvFromSlice(elems), nil
+}
+
+// jvHeap is a heap.Interface of Jvs with the smallest according to less at
+// the root.
+type jvHeap struct {
+	elems []*Jv
+	less  func(a, b *Jv) bool
+}
+
+func (h *jvHeap) Len() int           { return len(h.elems) }
+func (h *jvHeap) Less(i, j int) bool { return callLess(h.less, h.elems[i], h.elems[j]) }
+func (h *jvHeap) Swap(i, j int)      { h.elems[i], h.elems[j] = h.elems[j], h.elems[i] }
+func (h *jvHeap) Push(x interface{}) { h.elems = append(h.elems, x.(*Jv)) }
+
+func (h *jvHeap) Pop() interface{} {
+	last := h.elems[len(h.elems)-1]
+	h.elems = h.elems[:len(h.elems)-1]
+	return last
+}
+
+// DistinctBy returns a new array-typed jv of the elements of jv for which fn
+// returns a key that no earlier element has, keeping the first occurrence of
+// each. Keys are equal when they serialize to the same JSON, regardless of the
+// order of the keys of objects. fn is given a copy of each element, which is
+// freed once it returns, and the key it returns is consumed, even alongside an
+// error. A nil key is the same as null.
+//
+// Returns a *KindError if jv is not an array, or the first error returned by
+// fn.
+//
+// Does not consume the invocant.
+func (jv *Jv) DistinctBy(fn func(*Jv) (*Jv, error)) (*Jv, error) {
+	elems, err := jv.arrayElems("DistinctBy")
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	result := JvArray()
+	for i, elem := range elems {
+		elemCopy := elem.Copy()
+		key, err := fn(elemCopy)
+		elemCopy.Free()
+		if err != nil {
+			if key != nil {
+				key.Free()
+			}
+			freeAll(elems[i:])
+			result.Free()
+			return nil, err
+		}
+		if key == nil {
+			key = JvNull()
+		}
+
+		serialized := key.Dump(JvPrintSorted)
+		if seen[serialized] {
+			elem.Free()
+			continue
+		}
+		seen[serialized] = true
+		result = result.ArrayAppend(elem)
+	}
+	return result, nil
+}
+
+// All reports whether fn returns true for every element of an array-typed
+// jv, stopping at the first element for which it returns false. It is the
+// same as AllMatch, but with a Go predicate. fn is given a copy of each
+// element, which is freed once it returns.
+//
+// Returns a *KindError if jv is not an array, or the first error returned by
+// fn.
+//
+// Does not consume the invocant.
+func (jv *Jv) All(fn func(*Jv) (bool, error)) (bool, error) {
+	return jv.anyElem("All", false, fn)
+}
+
+// Any reports whether fn returns true for any element of an array-typed jv,
+// stopping at the first element for which it does. It is the same as
+// AnyMatch, but with a Go predicate. fn is given a copy of each element,
+// which is freed once it returns.
+//
+// Returns a *KindError if jv is not an array, or the first error returned by
+// fn.
+//
+// Does not consume the invocant.
+func (jv *Jv) Any(fn func(*Jv) (bool, error)) (bool, error) {
+	return jv.anyElem("Any", true, fn)
+}
+
+// None reports whether fn returns false for every element of an array-typed
+// jv, stopping at the first element for which it returns true. fn is given a
+// copy of each element, which is freed once it returns.
+//
+// Returns a *KindError if jv is not an array, or the first error returned by
+// fn.
+//
+// Does not consume the invocant.
+func (jv *Jv) None(fn func(*Jv) (bool, error)) (bool, error) {
+	any, err := jv.anyElem("None", true, fn)
+	if err != nil {
+		return false, err
+	}
+	return !any, nil
+}
+
+// CountBy returns the number of elements of an array-typed jv for which fn
+// returns true, without building an array of them. fn is given a copy of each
+// element, which is freed once it returns.
+//
+// Returns a *KindError if jv is not an array, or the first error returned by
+// fn.
+//
+// Does not consume the invocant.
+func (jv *Jv) CountBy(fn func(*Jv) (bool, error)) (int, error) {
+	if jv.Kind() != JvKindArray {
+		return 0, &KindError{Op: "CountBy", Kind: jv.Kind()}
+	}
+
+	count := 0
+	length := jv.Copy().ArrayLength()
+	for i := 0; i < length; i++ {
+		elem := jv.Copy().ArrayGet(i)
+		ok, err := fn(elem)
+		elem.Free()
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// SumBy returns the sum of the numbers fn returns for each element of an
+// array-typed jv, which is 0 if it is empty. fn is given a copy of each
+// element, which is freed once it returns.
+//
+// Returns a *KindError if jv is not an array, or the first error returned by
+// fn.
+//
+// Does not consume the invocant.
+func (jv *Jv) SumBy(fn func(*Jv) (float64, error)) (float64, error) {
+	sum := 0.0
+	if _, err := jv.projectNumbers("SumBy", fn, func(f float64) { sum += f }); err != nil {
+		return 0, err
+	}
+	return sum, nil
+}
+
+// MinVal returns the smallest of the numbers fn returns for each element of
+// an array-typed jv. It is otherwise the same as SumBy.
+//
+// Returns an error if jv is empty.
+//
+// Does not consume the invocant.
+func (jv *Jv) MinVal(fn func(*Jv) (float64, error)) (float64, error) {
+	smallest := math.Inf(1)
+	n, err := jv.projectNumbers("MinVal", fn, func(f float64) { smallest = math.Min(smallest, f) })
+	if err == nil && n == 0 {
+		err = errors.New("MinVal: array is empty")
+	}
+	if err != nil {
+		return 0, err
+	}
+	return smallest, nil
+}
+
+// MaxVal returns the largest of the numbers fn returns for each element of
+// an array-typed jv. It is otherwise the same as SumBy.
+//
+// Returns an error if jv is empty.
+//
+// Does not consume the invocant.
+func (jv *Jv) MaxVal(fn func(*Jv) (float64, error)) (float64, error) {
+	largest := math.Inf(-1)
+	n, err := jv.projectNumbers("MaxVal", fn, func(f float64) { largest = math.Max(largest, f) })
+	if err == nil && n == 0 {
+		err = errors.New("MaxVal: array is empty")
+	}
+	if err != nil {
+		return 0, err
+	}
+	return largest, nil
+}
+
+// Average returns the mean of the numbers fn returns for each element of an
+// array-typed jv. It is otherwise the same as SumBy.
+//
+// Returns an error if jv is empty.
+//
+// Does not consume the invocant.
+func (jv *Jv) Average(fn func(*Jv) (float64, error)) (float64, error) {
+	sum := 0.0
+	n, err := jv.projectNumbers("Average", fn, func(f float64) { sum += f })
+	if err == nil && n == 0 {
+		err = errors.New("Average: array is empty")
+	}
+	if err != nil {
+		return 0, err
+	}
+	return sum / float64(n), nil
+}
+
+// projectNumbers passes the number fn returns for each element of jv to
+// visit, returning the number of elements.
+//
+// Does not consume the invocant.
+func (jv *Jv) projectNumbers(op string, fn func(*Jv) (float64, error), visit func(float64)) (int, error) {
+	if jv.Kind() != JvKindArray {
+		return 0, &KindError{Op: op, Kind: jv.Kind()}
+	}
+
+	length := jv.Copy().ArrayLength()
+	for i := 0; i < length; i++ {
+		elem := jv.Copy().ArrayGet(i)
+		f, err := fn(elem)
+		elem.Free()
+		if err != nil {
+			return 0, err
+		}
+		visit(f)
+	}
+	return length, nil
+}
+
+// anyElem reports whether fn returns want for any element of jv, or the
+// opposite of want if it does for none of them.
+//
+// Does not consume the invocant.
+func (jv *Jv) anyElem(op string, want bool, fn func(*Jv) (bool, error)) (bool, error) {
+	if jv.Kind() != JvKindArray {
+		return false, &KindError{Op: op, Kind: jv.Kind()}
+	}
+
+	length := jv.Copy().ArrayLength()
+	for i := 0; i < length; i++ {
+		elem := jv.Copy().ArrayGet(i)
+		ok, err := fn(elem)
+		elem.Free()
+		if err != nil {
+			return false, err
+		}
+		if ok == want {
+			return want, nil
+		}
+	}
+	return !want, nil
+}
+
+// Deduplicate returns a new array-typed jv of the elements of jv without
+// duplicates, keeping the first occurrence of each in its original order,
+// unlike jq's `unique`, which sorts them. Elements are equal when they
+// serialize to the same JSON, regardless of the order of the keys of objects.
+//
+// Returns a *KindError if jv is not an array.
+//
+// Does not consume the invocant.
+func (jv *Jv) Deduplicate() (*Jv, error) {
+	elems, err := jv.arrayElems("Deduplicate")
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	result := JvArray()
+	for _, elem := range elems {
+		serialized := elem.Copy().Dump(JvPrintSorted)
+		if seen[serialized] {
+			elem.Free()
+			continue
+		}
+		seen[serialized] = true
+		result = result.ArrayAppend(elem)
+	}
+	return result, nil
+}
+
+// Interleave returns a new array-typed jv alternating the elements of jv and
+// other, starting with jv, such that [a, b, c] and [x, y, z] give
+// [a, x, b, y, c, z]. The shorter array is padded with null.
+//
+// Returns a *KindError if jv or other is not an array.
+//
+// Consumes other. Does not consume the invocant.
+func (jv *Jv) Interleave(other *Jv) (*Jv, error) {
+	defer other.Free()
+
+	if jv.Kind() != JvKindArray {
+		return nil, &KindError{Op: "Interleave", Kind: jv.Kind()}
+	}
+	if other.Kind() != JvKindArray {
+		return nil, &KindError{Op: "Interleave", Kind: other.Kind()}
+	}
+
+	length, otherLength := jv.Copy().ArrayLength(), other.Copy().ArrayLength()
+	elemOrNull := func(ary *Jv, aryLength, i int) *Jv {
+		if i < aryLength {
+			return ary.Copy().ArrayGet(i)
+		}
+		return JvNull()
+	}
+
+	result := JvArray()
+	for i := 0; i < length || i < otherLength; i++ {
+		result = result.ArrayAppend(elemOrNull(jv, length, i))
+		result = result.ArrayAppend(elemOrNull(other, otherLength, i))
+	}
+	return result, nil
+}
+
+// RotateLeft returns a new array-typed jv of the elements of jv rotated n
+// positions to the left, such that [1, 2, 3] rotated by 1 gives [2, 3, 1]. A
+// negative n rotates to the right, and n wraps around the length of jv.
+//
+// Returns a *KindError if jv is not an array.
+//
+// Does not consume the invocant.
+func (jv *Jv) RotateLeft(n int) (*Jv, error) {
+	if jv.Kind() != JvKindArray {
+		return nil, &KindError{Op: "RotateLeft", Kind: jv.Kind()}
+	}
+	return jv.rotate(n), nil
+}
+
+// RotateRight returns a new array-typed jv of the elements of jv rotated n
+// positions to the right, such that [1, 2, 3] rotated by 1 gives [3, 1, 2]. A
+// negative n rotates to the left, and n wraps around the length of jv.
+//
+// Returns a *KindError if jv is not an array.
+//
+// Does not consume the invocant.
+func (jv *Jv) RotateRight(n int) (*Jv, error) {
+	if jv.Kind() != JvKindArray {
+		return nil, &KindError{Op: "RotateRight", Kind: jv.Kind()}
+	}
+	return jv.rotate(-n), nil
+}
+
+// rotate rotates an array-typed jv n positions to the left.
+//
+// Does not consume the invocant.
+func (jv *Jv) rotate(n int) *Jv {
+	length := jv.Copy().ArrayLength()
+	result := JvArray()
+	if length == 0 {
+		return result
+	}
+
+	start := (n%length + length) % length
+	for i := 0; i < length; i++ {
+		result = result.ArrayAppend(jv.Copy().ArrayGet((start + i) % length))
+	}
+	return result
+}
+
+// callLess calls less with copies of a and b, freeing them once it returns.
+//
+// Does not consume a or b.
+func callLess(less func(a, b *Jv) bool, a, b *Jv) bool {
+	aCopy, bCopy := a.Copy(), b.Copy()
+	defer aCopy.Free()
+	defer bCopy.Free()
+	return less(aCopy, bCopy)
+}
+
+// Keys returns the keys of an object-typed jv in lexicographic order, like
+// jq's `keys`, or the indices of an array-typed jv. The caller must free each
+// of them.
+//
+// Returns a *KindError if jv is not an object or array.
+//
+// Does not consume the invocant.
+func (jv *Jv) Keys() ([]*Jv, error) {
+	switch jv.Kind() {
+	case JvKindObject:
+		names, _ := jv.SortedKeys()
+		keys := make([]*Jv, len(names))
+		for i, name := range names {
+			keys[i] = JvFromString(name)
+		}
+		return keys, nil
+	case JvKindArray:
+		keys := make([]*Jv, jv.Copy().ArrayLength())
+		for i := range keys {
+			keys[i] = JvFromFloat(float64(i))
+		}
+		return keys, nil
+	default:
+		return nil, &KindError{Op: "Keys", Kind: jv.Kind()}
+	}
+}
+
+// Values returns the values of an object-typed jv in the order of their keys
+// as returned by Keys, or the elements of an array-typed jv. The caller must
+// free each of them.
+//
+// Returns a *KindError if jv is not an object or array.
+//
+// Does not consume the invocant.
+func (jv *Jv) Values() ([]*Jv, error) {
+	switch jv.Kind() {
+	case JvKindObject:
+		names, _ := jv.SortedKeys()
+		values := make([]*Jv, len(names))
+		for i, name := range names {
+			values[i] = &Jv{C.jv_object_get(jv.Copy().jv, JvFromString(name).jv)}
+		}
+		return values, nil
+	case JvKindArray:
+		return jv.arrayElems("Values")
+	default:
+		return nil, &KindError{Op: "Values", Kind: jv.Kind()}
+	}
+}
+
+// pathComponentError describes the component of a path at which Getpath,
+// Setpath, GetPath or SetPath failed.
+func pathComponentError(path *Jv, i int, msg string) error {
+	component := path.Copy().ArrayGet(i).Dump(JvPrintNone)
+	return fmt.Errorf("path component %d (%s): %s", i, component, msg)
+}
+
+// invalidMessage returns the error message of an invalid jv.
+//
+// Consumes the invocant.
+func invalidMessage(invalid *Jv) string {
+	msg, _ := invalid.GetInvalidMessageAsString()
+	return msg
+}
+
+// Getpath returns the value at path within jv using libjq's `jv_getpath`.
+// Unlike jq's `getpath(path)`, which results in null, a missing object key,
+// an out of range array index or a null value along the way is an error. Use
+// GetPath for jq's behaviour.
+//
+// Returns a *KindError if path is not an array, or an error naming the
+// offending path component if it is missing or cannot be applied, such as
+// indexing a number.
+//
+// Consumes path. Does not consume the invocant.
+func (jv *Jv) Getpath(path *Jv) (*Jv, error) {
+	defer path.Free()
+
+	if path.Kind() != JvKindArray {
+		return nil, &KindError{Op: "Getpath", Kind: path.Kind()}
+	}
+
+	result := &Jv{C.jv_getpath(jv.Copy().jv, path.Copy().jv)}
+	if result.IsValid() && result.Kind() != JvKindNull {
+		return result, nil
+	}
+
+	// jv_getpath results in null for a missing component and doesn't say
+	// which component it couldn't apply, so walk the path to find out.
+	if err := checkPath(jv, path); err != nil {
+		result.Free()
+		return nil, err
+	}
+	if !result.IsValid() {
+		return nil, fmt.Errorf("path %s: %s", path.Copy().Dump(JvPrintNone), invalidMessage(result))
+	}
+	return result, nil
+}
+
+// checkPath returns an error naming the first component of path that is
+// missing from jv or cannot be applied to it, or nil if there isn't one.
+//
+// Does not consume jv or path.
+func checkPath(jv, path *Jv) error {
+	cur := jv.Copy()
+	length := path.Copy().ArrayLength()
+	for i := 0; i < length; i++ {
+		key := path.Copy().ArrayGet(i)
+		if msg := missingPathComponent(cur, key); msg != "" {
+			cur.Free()
+			key.Free()
+			return pathComponentError(path, i, msg)
+		}
+
+		cur = &Jv{C.jv_get(cur.jv, key.jv)}
+		if !cur.IsValid() {
+			return pathComponentError(path, i, invalidMessage(cur))
+		}
+	}
+	cur.Free()
+	return nil
+}
+
+// missingPathComponent describes why key is missing from t, or returns "" if
+// it isn't. Keys that cannot be applied to t aren't missing, so that jv_get
+// can report why.
+//
+// Does not consume t or key.
+func missingPathComponent(t, key *Jv) string {
+	switch {
+	case t.Kind() == JvKindNull:
+		return "cannot index null"
+	case t.Kind() == JvKindObject && key.Kind() == JvKindString:
+		if C.jv_object_has(t.Copy().jv, key.Copy().jv) == 0 {
+			return "no such key"
+		}
+	case t.Kind() == JvKindArray && key.Kind() == JvKindNumber:
+		f := float64(C.jv_number_value(key.jv))
+		length := t.Copy().ArrayLength()
+		idx := int(f)
+		if idx < 0 {
+			idx += length
+		}
+		if f != math.Trunc(f) || idx < 0 || idx >= length {
+			return "index out of range"
+		}
+	}
+	return ""
+}
+
+// Setpath returns a copy of jv with the value at path replaced by value using
+// libjq's `jv_setpath`, like jq's `setpath(path; value)`. Objects and arrays
+// are created for any missing or null values along the way.
+//
+// Returns a *KindError if path is not an array, or an error naming the
+// offending path component if it cannot be applied, such as setting a key
+// within a number.
+//
+// Consumes path and value. Does not consume the invocant.
+func (jv *Jv) Setpath(path, value *Jv) (*Jv, error) {
+	defer path.Free()
+
+	if path.Kind() != JvKindArray {
+		value.Free()
+		return nil, &KindError{Op: "Setpath", Kind: path.Kind()}
+	}
+
+	result := &Jv{C.jv_setpath(jv.Copy().jv, path.Copy().jv, value.jv)}
+	if !result.IsValid() {
+		return nil, pathComponentError(path, invalidPathComponent(jv, path), invalidMessage(result))
+	}
+	return result, nil
+}
+
+// invalidPathComponent returns the index of the first component of path that
+// jv_get cannot apply within jv, or of the last component if there isn't one,
+// as jv_setpath doesn't say which component it couldn't apply.
+//
+// Does not consume jv or path.
+func invalidPathComponent(jv, path *Jv) int {
+	cur := jv.Copy()
+	length := path.Copy().ArrayLength()
+	for i := 0; i < length; i++ {
+		cur = &Jv{C.jv_get(cur.jv, path.Copy().ArrayGet(i).jv)}
+		if !cur.IsValid() {
+			cur.Free()
+			return i
+		}
+	}
+	cur.Free()
+	return length - 1
+}
+
+// GetPath returns the value at path within jv, where path is an array of
+// object keys and array indexes, walking jv with ObjectGet and ArrayGet rather
+// than libjq. As in jq's `getpath(path)`, a missing object key, an out of
+// range array index or a null value along the way results in null, and
+// negative indexes count from the end of an array.
+//
+// As in a JSON Pointer (RFC 6901), a string of decimal digits such as "0" may
+// also index an array, so a pointer only needs to be split into its unescaped
+// reference tokens.
+//
+// Returns a *KindError if path is not an array, or an error naming the
+// offending path component if it cannot be applied, such as indexing a
+// number.
+//
+// Consumes path. Does not consume the invocant.
+func (jv *Jv) GetPath(path *Jv) (*Jv, error) {
+	defer path.Free()
+
+	if path.Kind() != JvKindArray {
+		return nil, &KindError{Op: "GetPath", Kind: path.Kind()}
+	}
+
+	cur := jv.Copy()
+	length := path.Copy().ArrayLength()
+	for i := 0; i < length; i++ {
+		key := path.Copy().ArrayGet(i)
+		switch {
+		case cur.Kind() == JvKindNull:
+			key.Free()
+			continue
+		case cur.Kind() == JvKindObject && key.Kind() == JvKindString:
+			next := cur.ObjectGet(key)
+			if !next.IsValid() {
+				next.Free()
+				next = JvNull()
+			}
+			cur = next
+			continue
+		case cur.Kind() == JvKindArray:
+			if idx, ok := pathIndex(key, cur.Copy().ArrayLength()); ok {
+				key.Free()
+				if idx < 0 || idx >= cur.Copy().ArrayLength() {
+					cur.Free()
+					cur = JvNull()
+					continue
+				}
+				cur = cur.ArrayGet(idx)
+				continue
+			}
+		}
+
+		err := pathComponentError(path, i, fmt.Sprintf("cannot index %s with %s", cur.Kind(), key.Kind()))
+		cur.Free()
+		key.Free()
+		return nil, err
+	}
+	return cur, nil
+}
+
+// SetPath returns a copy of jv with the value at path replaced by value,
+// walking jv with ObjectGet and ArrayGet rather than libjq. As in jq's
+// `setpath(path; value)`, objects are created for any missing or null values
+// along the way, or arrays if they are indexed by a number, and arrays are
+// padded with nulls. Array indexes are given as for GetPath.
+//
+// Returns a *KindError if path is not an array, or an error naming the
+// offending path component if it cannot be applied, such as setting a key
+// within a number.
+//
+// Consumes path and value. Does not consume the invocant.
+func (jv *Jv) SetPath(path, value *Jv) (*Jv, error) {
+	defer path.Free()
+
+	if path.Kind() != JvKindArray {
+		value.Free()
+		return nil, &KindError{Op: "SetPath", Kind: path.Kind()}
+	}
+	return setPathIn(jv.Copy(), path, 0, value)
+}
+
+// setPathIn replaces the value at path[i:] within t.
+//
+// Consumes t and value.
+func setPathIn(t, path *Jv, i int, value *Jv) (*Jv, error) {
+	if i == path.Copy().ArrayLength() {
+		t.Free()
+		return value, nil
+	}
+
+	key := path.Copy().ArrayGet(i)
+	if t.Kind() == JvKindNull {
+		t.Free()
+		if key.Kind() == JvKindNumber {
+			t = JvArray()
+		} else {
+			t = JvObject()
+		}
+	}
+
+	switch {
+	case t.Kind() == JvKindObject && key.Kind() == JvKindString:
+		child := t.Copy().ObjectGet(key.Copy())
+		if !child.IsValid() {
+			child.Free()
+			child = JvNull()
+		}
+		child, err := setPathIn(child, path, i+1, value)
+		if err != nil {
+			t.Free()
+			key.Free()
+			return nil, err
+		}
+		return t.ObjectSet(key, child), nil
+	case t.Kind() == JvKindArray:
+		length := t.Copy().ArrayLength()
+		if idx, ok := pathIndex(key, length); ok {
+			key.Free()
+			if idx < 0 {
+				t.Free()
+				value.Free()
+				return nil, pathComponentError(path, i, "out of bounds negative array index")
+			}
+
+			child := JvNull()
+			if idx < length {
+				child = t.Copy().ArrayGet(idx)
+			}
+			child, err := setPathIn(child, path, i+1, value)
+			if err != nil {
+				t.Free()
+				return nil, err
+			}
+			return t.ArraySet(idx, child), nil
+		}
+	}
+
+	err := pathComponentError(path, i, fmt.Sprintf("cannot index %s with %s", t.Kind(), key.Kind()))
+	t.Free()
+	key.Free()
+	value.Free()
+	return nil, err
+}
+
+// pathIndex returns the index of an array of the given length that key
+// refers to, counting negative numbers from the end of the array. ok is false
+// if key is neither a number nor a string of decimal digits.
+//
+// Does not consume key.
+func pathIndex(key *Jv, length int) (idx int, ok bool) {
+	switch key.Kind() {
+	case JvKindNumber:
+		idx = int(C.jv_number_value(key.jv))
+		if idx < 0 {
+			idx += length
+		}
+		return idx, true
+	case JvKindString:
+		str := key._string()
+		idx, err := strconv.Atoi(str)
+		if err != nil || idx < 0 || strconv.Itoa(idx) != str {
+			return 0, false
+		}
+		return idx, true
+	default:
+		return 0, false
+	}
+}
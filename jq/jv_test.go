@@ -22,7 +22,12 @@
 package jq_test
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/jzelinskie/faq/jq"
@@ -70,6 +75,49 @@ func TestJvString(t *testing.T) {
 	}
 }
 
+func TestJvStringValue(t *testing.T) {
+	str := jq.JvFromString("test")
+	defer str.Free()
+	if value, err := str.StringValue(); err != nil || value != "test" {
+		t.Errorf(`StringValue() got: %q (%v), want: "test"`, value, err)
+	}
+
+	num := jq.JvFromFloat(1)
+	defer num.Free()
+	if _, err := num.StringValue(); err == nil {
+		t.Errorf("StringValue() of a number did not return an error")
+	}
+}
+
+func TestJvCompactJSON(t *testing.T) {
+	table := []struct {
+		testName string
+		input    string
+		output   string
+	}{
+		{"String", `"a b"`, `"a b"`},
+		{"Number", `1.5`, `1.5`},
+		{"Null", `null`, `null`},
+		{"Boolean", `true`, `true`},
+		{"Array", `[1, "x", [null]]`, `[1,"x",[null]]`},
+		{"Object", `{"a": {"b": [1, 2]}}`, `{"a":{"b":[1,2]}}`},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			input := mustParse(t, tt.input)
+			defer input.Free()
+
+			if output := input.CompactJSON(); output != tt.output {
+				t.Errorf("CompactJSON() got: %s, want: %s", output, tt.output)
+			}
+			if output := fmt.Sprintf("%#v", input); output != tt.output {
+				t.Errorf("%%#v got: %s, want: %s", output, tt.output)
+			}
+		})
+	}
+}
+
 func TestJvStringOnNonStringType(t *testing.T) {
 	jv := jq.JvNull()
 	defer jv.Free()
@@ -327,3 +375,1519 @@ func TestJvInvalidWithMessage_object(t *testing.T) {
 		t.Errorf(`JvInvalidWithMessage().JvGetInvalidMessageAsString() did not return "{}"`)
 	}
 }
+
+func TestJvToMap(t *testing.T) {
+	jv, err := jq.JvFromJSONString(`{"a": 1, "b": ["x"]}`)
+	if err != nil {
+		t.Fatalf("error when parsing jv from JSON string: %s", err)
+	}
+	defer jv.Free()
+
+	m, err := jv.ToMap()
+	if err != nil {
+		t.Fatalf("ToMap() on an object failed: %s", err)
+	}
+	if m["a"] != 1 {
+		t.Errorf(`ToMap()["a"] got: %v, want: 1`, m["a"])
+	}
+	if m["b"].([]interface{})[0] != "x" {
+		t.Errorf(`ToMap()["b"][0] got: %v, want: x`, m["b"])
+	}
+
+	arr := jq.JvArray()
+	defer arr.Free()
+	_, err = arr.ToMap()
+	if kerr, ok := err.(*jq.KindError); !ok || kerr.Kind != jq.JvKindArray {
+		t.Errorf("ToMap() on an array got: %v, want: *KindError", err)
+	}
+}
+
+func TestJvToSlice(t *testing.T) {
+	jv, err := jq.JvFromJSONString(`[1, "two", null]`)
+	if err != nil {
+		t.Fatalf("error when parsing jv from JSON string: %s", err)
+	}
+	defer jv.Free()
+
+	s, err := jv.ToSlice()
+	if err != nil {
+		t.Fatalf("ToSlice() on an array failed: %s", err)
+	}
+	if len(s) != 3 || s[0] != 1 || s[1] != "two" || s[2] != nil {
+		t.Errorf("ToSlice() got: %v, want: [1 two <nil>]", s)
+	}
+
+	obj := jq.JvObject()
+	defer obj.Free()
+	_, err = obj.ToSlice()
+	if kerr, ok := err.(*jq.KindError); !ok || kerr.Kind != jq.JvKindObject {
+		t.Errorf("ToSlice() on an object got: %v, want: *KindError", err)
+	}
+}
+
+func TestJvObjectForEach(t *testing.T) {
+	jv, err := jq.JvFromJSONString(`{"b": 1, "a": 2, "c": 3}`)
+	if err != nil {
+		t.Fatalf("error when parsing jv from JSON string: %s", err)
+	}
+	defer jv.Free()
+
+	var keys []string
+	err = jv.ObjectForEach(func(key string, value *jq.Jv) error {
+		keys = append(keys, key)
+		if key == "a" {
+			return fmt.Errorf("stop")
+		}
+		return nil
+	})
+	if err == nil || err.Error() != "stop" {
+		t.Errorf("ObjectForEach() did not return the error from fn, got: %v", err)
+	}
+	if len(keys) != 2 || keys[0] != "b" || keys[1] != "a" {
+		t.Errorf("ObjectForEach() visited keys: %v, want: [b a]", keys)
+	}
+}
+
+func TestJvSortedKeys(t *testing.T) {
+	jv, err := jq.JvFromJSONString(`{"b": 1, "a": 2, "c": {"z": 1, "y": 2}}`)
+	if err != nil {
+		t.Fatalf("error when parsing jv from JSON string: %s", err)
+	}
+	defer jv.Free()
+
+	keys, err := jv.SortedKeys()
+	if err != nil {
+		t.Fatalf("SortedKeys() on an object failed: %s", err)
+	}
+	if fmt.Sprint(keys) != "[a b c]" {
+		t.Errorf("SortedKeys() got: %v, want: [a b c]", keys)
+	}
+
+	str := jq.JvFromString("abc")
+	defer str.Free()
+	if _, err := str.SortedKeys(); err == nil {
+		t.Errorf("SortedKeys() on a string did not return an error")
+	}
+}
+
+func TestJvNumberInRange(t *testing.T) {
+	table := []struct {
+		testName string
+		n        float64
+		min, max float64
+		inRange  bool
+		clamped  float64
+	}{
+		{"Inside", 5, 0, 10, true, 5},
+		{"Min", 0, 0, 10, true, 0},
+		{"Max", 10, 0, 10, true, 10},
+		{"Below", -1.5, 0, 10, false, 0},
+		{"Above", 11, 0, 10, false, 10},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			jv := jq.JvFromFloat(tt.n)
+			defer jv.Free()
+
+			inRange, err := jv.NumberInRange(tt.min, tt.max)
+			if err != nil {
+				t.Fatalf("NumberInRange() failed: %s", err)
+			}
+			if inRange != tt.inRange {
+				t.Errorf("NumberInRange() got: %t, want: %t", inRange, tt.inRange)
+			}
+
+			clamped := jv.NumberClamp(tt.min, tt.max)
+			defer clamped.Free()
+			if n, _ := clamped.ToFloat64(); n != tt.clamped {
+				t.Errorf("NumberClamp() got: %v, want: %v", n, tt.clamped)
+			}
+		})
+	}
+
+	str := jq.JvFromString("5")
+	defer str.Free()
+	_, err := str.NumberInRange(0, 10)
+	if kerr, ok := err.(*jq.KindError); !ok || kerr.Kind != jq.JvKindString {
+		t.Errorf("NumberInRange() on a string got: %v, want: *KindError", err)
+	}
+
+	clamped := str.NumberClamp(0, 10)
+	defer clamped.Free()
+	if clamped.Kind() != jq.JvKindString {
+		t.Errorf("NumberClamp() on a string got kind: %s, want: string", clamped.Kind())
+	}
+}
+
+func TestJvNormalize(t *testing.T) {
+	input := jq.JvArray().
+		ArrayAppend(jq.JvFromFloat(math.NaN())).
+		ArrayAppend(jq.JvFromFloat(math.Inf(1))).
+		ArrayAppend(jq.JvFromFloat(math.Inf(-1))).
+		ArrayAppend(jq.JvFromFloat(1.5)).
+		ArrayAppend(jq.JvObject().
+			ObjectSet(jq.JvFromString("b"), jq.JvArray().ArrayAppend(jq.JvFromFloat(math.NaN()))).
+			ObjectSet(jq.JvFromString("a"), jq.JvFromString("faq")))
+	defer input.Free()
+
+	if _, err := json.Marshal(input.ToGoVal()); err == nil {
+		t.Fatal("json.Marshal() of the input unexpectedly succeeded")
+	}
+
+	normalized := input.Normalize()
+	defer normalized.Free()
+
+	b, err := json.Marshal(normalized.ToGoVal())
+	if err != nil {
+		t.Fatalf("json.Marshal() failed: %s", err)
+	}
+	if want := `[null,null,null,1.5,{"a":"faq","b":[null]}]`; string(b) != want {
+		t.Errorf("Normalize() got: %s, want: %s", b, want)
+	}
+	if got, want := normalized.Copy().Dump(jq.JvPrintNone), `[null,null,null,1.5,{"b":[null],"a":"faq"}]`; got != want {
+		t.Errorf("Normalize() got: %s, want: %s", got, want)
+	}
+}
+
+func TestJvAsciiCase(t *testing.T) {
+	table := []struct {
+		testName string
+		input    string
+		lower    string
+		upper    string
+	}{
+		{"ASCII", "Hello, World", "hello, world", "HELLO, WORLD"},
+		{"Unicode", "Ça Élève", "ça élève", "ÇA ÉLÈVE"},
+		{"Empty", "", "", ""},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			jv := jq.JvFromString(tt.input)
+			defer jv.Free()
+
+			lower, err := jv.ASCIIDowncase()
+			if err != nil {
+				t.Fatalf("ASCIIDowncase() failed: %s", err)
+			}
+			defer lower.Free()
+			if str, _ := lower.StringValue(); str != tt.lower {
+				t.Errorf("ASCIIDowncase() got: %q, want: %q", str, tt.lower)
+			}
+
+			upper, err := jv.ASCIIUpcase()
+			if err != nil {
+				t.Fatalf("ASCIIUpcase() failed: %s", err)
+			}
+			defer upper.Free()
+			if str, _ := upper.StringValue(); str != tt.upper {
+				t.Errorf("ASCIIUpcase() got: %q, want: %q", str, tt.upper)
+			}
+		})
+	}
+
+	num := jq.JvFromFloat(1)
+	defer num.Free()
+	if _, err := num.ASCIIDowncase(); err == nil {
+		t.Errorf("ASCIIDowncase() on a number did not return an error")
+	}
+	if _, err := num.ASCIIUpcase(); err == nil {
+		t.Errorf("ASCIIUpcase() on a number did not return an error")
+	}
+}
+
+func TestJvTrim(t *testing.T) {
+	table := []struct {
+		testName string
+		input    string
+		trim     func(*jq.Jv) (*jq.Jv, error)
+		output   string
+	}{
+		{"Ltrimstr", "foobar", func(jv *jq.Jv) (*jq.Jv, error) { return jv.Ltrimstr("foo") }, "bar"},
+		{"LtrimstrNoMatch", "foobar", func(jv *jq.Jv) (*jq.Jv, error) { return jv.Ltrimstr("bar") }, "foobar"},
+		{"LtrimstrOnce", "foofoo", func(jv *jq.Jv) (*jq.Jv, error) { return jv.Ltrimstr("foo") }, "foo"},
+		{"Rtrimstr", "foobar", func(jv *jq.Jv) (*jq.Jv, error) { return jv.Rtrimstr("bar") }, "foo"},
+		{"RtrimstrNoMatch", "foobar", func(jv *jq.Jv) (*jq.Jv, error) { return jv.Rtrimstr("foo") }, "foobar"},
+		{"Ltrim", " \t\n foo ", (*jq.Jv).Ltrim, "foo "},
+		{"Rtrim", " foo \t\n ", (*jq.Jv).Rtrim, " foo"},
+		{"LtrimAllSpace", "   ", (*jq.Jv).Ltrim, ""},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			jv := jq.JvFromString(tt.input)
+			defer jv.Free()
+
+			result, err := tt.trim(jv)
+			if err != nil {
+				t.Fatalf("%s() failed: %s", tt.testName, err)
+			}
+			defer result.Free()
+			if str, _ := result.StringValue(); str != tt.output {
+				t.Errorf("%s() got: %q, want: %q", tt.testName, str, tt.output)
+			}
+		})
+	}
+
+	num := jq.JvFromFloat(1)
+	defer num.Free()
+	if _, err := num.Ltrimstr("1"); err == nil {
+		t.Errorf("Ltrimstr() on a number did not return an error")
+	}
+	if _, err := num.Rtrim(); err == nil {
+		t.Errorf("Rtrim() on a number did not return an error")
+	}
+}
+
+func TestJvSplitJoin(t *testing.T) {
+	table := []struct {
+		testName string
+		input    string
+		sep      string
+		parts    string
+	}{
+		{"Simple", "a,b,c", ",", `["a","b","c"]`},
+		{"EmptyParts", "a,,b", ",", `["a","","b"]`},
+		{"MultiCharSep", "a::b", "::", `["a","b"]`},
+		{"NoSep", "abc", ",", `["abc"]`},
+		{"Empty", "", ",", `[]`},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			jv := jq.JvFromString(tt.input)
+			defer jv.Free()
+
+			parts, err := jv.Split(tt.sep)
+			if err != nil {
+				t.Fatalf("Split() failed: %s", err)
+			}
+			defer parts.Free()
+			if dump := parts.Copy().Dump(jq.JvPrintNone); dump != tt.parts {
+				t.Errorf("Split() got: %s, want: %s", dump, tt.parts)
+			}
+
+			joined, err := parts.Join(tt.sep)
+			if err != nil {
+				t.Fatalf("Join() failed: %s", err)
+			}
+			defer joined.Free()
+			if str, _ := joined.StringValue(); str != tt.input {
+				t.Errorf("Join() got: %q, want: %q", str, tt.input)
+			}
+		})
+	}
+
+	num := jq.JvFromFloat(1)
+	defer num.Free()
+	if _, err := num.Split(","); err == nil {
+		t.Errorf("Split() on a number did not return an error")
+	}
+	if _, err := num.Join(","); err == nil {
+		t.Errorf("Join() on a number did not return an error")
+	}
+
+	mixed := jq.JvFromStringSlice([]string{"a"}).ArrayAppend(jq.JvFromFloat(1))
+	defer mixed.Free()
+	if _, err := mixed.Join(","); err == nil {
+		t.Errorf("Join() on an array containing a number did not return an error")
+	}
+}
+
+func TestJvExplode(t *testing.T) {
+	table := []struct {
+		testName   string
+		input      string
+		codepoints string
+	}{
+		{"ASCII", "abc", `[97,98,99]`},
+		{"CJK", "中文", `[20013,25991]`},
+		{"Emoji", "😀", `[128512]`},
+		{"Combining", "é", `[101,769]`},
+		{"Empty", "", `[]`},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			jv := jq.JvFromString(tt.input)
+			defer jv.Free()
+
+			codepoints, err := jv.Explode()
+			if err != nil {
+				t.Fatalf("Explode() failed: %s", err)
+			}
+			if dump := codepoints.Copy().Dump(jq.JvPrintNone); dump != tt.codepoints {
+				t.Errorf("Explode() got: %s, want: %s", dump, tt.codepoints)
+			}
+
+			imploded, err := jq.JvFromCodepoints(codepoints)
+			if err != nil {
+				t.Fatalf("JvFromCodepoints() failed: %s", err)
+			}
+			defer imploded.Free()
+			if str, _ := imploded.StringValue(); str != tt.input {
+				t.Errorf("JvFromCodepoints() got: %q, want: %q", str, tt.input)
+			}
+		})
+	}
+
+	num := jq.JvFromFloat(1)
+	defer num.Free()
+	if _, err := num.Explode(); err == nil {
+		t.Errorf("Explode() on a number did not return an error")
+	}
+
+	for _, invalid := range []string{`"abc"`, `[97, "b"]`, `[97.5]`, `[-1]`, `[55296]`, `[1114112]`} {
+		jv, err := jq.JvFromJSONString(invalid)
+		if err != nil {
+			t.Fatalf("error when parsing jv from JSON string: %s", err)
+		}
+		if _, err := jq.JvFromCodepoints(jv); err == nil {
+			t.Errorf("JvFromCodepoints(%s) did not return an error", invalid)
+		}
+	}
+}
+
+func TestJvTojsonFromjson(t *testing.T) {
+	table := []struct {
+		testName string
+		input    string
+		json     string
+	}{
+		{"Object", `{"a": [1, "two", null]}`, `{"a":[1,"two",null]}`},
+		{"String", `"quote \" and newline \n"`, `"quote \" and newline \n"`},
+		{"Number", `1.5`, `1.5`},
+		{"Null", `null`, `null`},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			jv := mustParse(t, tt.input)
+			defer jv.Free()
+
+			str, err := jv.Tojson()
+			if err != nil {
+				t.Fatalf("Tojson() failed: %s", err)
+			}
+			defer str.Free()
+			if got, _ := str.StringValue(); got != tt.json {
+				t.Errorf("Tojson() got: %s, want: %s", got, tt.json)
+			}
+
+			parsed, err := str.Fromjson()
+			if err != nil {
+				t.Fatalf("Fromjson() failed: %s", err)
+			}
+			if dump := parsed.Dump(jq.JvPrintNone); dump != tt.json {
+				t.Errorf("Fromjson() got: %s, want: %s", dump, tt.json)
+			}
+		})
+	}
+
+	num := jq.JvFromFloat(1)
+	defer num.Free()
+	if _, err := num.Fromjson(); err == nil {
+		t.Errorf("Fromjson() on a number did not return an error")
+	}
+
+	invalid := jq.JvFromString("{")
+	defer invalid.Free()
+	if _, err := invalid.Fromjson(); err == nil {
+		t.Errorf("Fromjson() with invalid JSON did not return an error")
+	}
+}
+
+func TestJvGetpath(t *testing.T) {
+	jv := mustParse(t, `{"a": {"b": [10, 20]}, "n": null, "x": 1}`)
+	defer jv.Free()
+
+	table := []struct {
+		testName string
+		path     string
+		output   string
+		err      bool
+	}{
+		{"Empty", `[]`, `{"a":{"b":[10,20]},"n":null,"x":1}`, false},
+		{"Nested", `["a", "b", 1]`, `20`, false},
+		{"NegativeIndex", `["a", "b", -1]`, `20`, false},
+		{"NullValue", `["n"]`, `null`, false},
+		{"Missing", `["a", "c"]`, ``, true},
+		{"OutOfRange", `["a", "b", 5]`, ``, true},
+		{"NegativeOutOfRange", `["a", "b", -3]`, ``, true},
+		{"ThroughNull", `["n", "b", 0]`, ``, true},
+		{"ThroughMissing", `["c", "d"]`, ``, true},
+		{"IndexNumber", `["x", "y"]`, ``, true},
+		{"IndexArrayWithString", `["a", "b", "c"]`, ``, true},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			result, err := jv.Getpath(mustParse(t, tt.path))
+			if (err != nil) != tt.err {
+				t.Fatalf("Getpath() got error: %v, want error: %t", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if dump := result.Dump(jq.JvPrintNone); dump != tt.output {
+				t.Errorf("Getpath() got: %s, want: %s", dump, tt.output)
+			}
+		})
+	}
+
+	errTable := []struct {
+		path string
+		err  string
+	}{
+		{`["x", "y"]`, `path component 1 ("y"): `},
+		{`["a", "c"]`, `path component 1 ("c"): no such key`},
+		{`["a", "b", 5]`, `path component 2 (5): index out of range`},
+		{`["n", "b", 0]`, `path component 1 ("b"): cannot index null`},
+	}
+	for _, tt := range errTable {
+		_, err := jv.Getpath(mustParse(t, tt.path))
+		if err == nil || !strings.HasPrefix(err.Error(), tt.err) {
+			t.Errorf("Getpath() got error: %v, want prefix: %s", err, tt.err)
+		}
+	}
+
+	if _, err := jv.Getpath(jq.JvFromString("a")); err == nil {
+		t.Errorf("Getpath() with a string path did not return an error")
+	}
+}
+
+func TestJvSetpath(t *testing.T) {
+	jv := mustParse(t, `{"a": {"b": [10, 20]}, "n": null, "x": 1}`)
+	defer jv.Free()
+
+	table := []struct {
+		testName string
+		path     string
+		output   string
+		err      bool
+	}{
+		{"Existing", `["a", "b", 0]`, `{"a":{"b":[true,20]},"n":null,"x":1}`, false},
+		{"NewKey", `["a", "c"]`, `{"a":{"b":[10,20],"c":true},"n":null,"x":1}`, false},
+		{"NewNested", `["new", "deep", 1]`, `{"a":{"b":[10,20]},"n":null,"x":1,"new":{"deep":[null,true]}}`, false},
+		{"ThroughNull", `["n", "b"]`, `{"a":{"b":[10,20]},"n":{"b":true},"x":1}`, false},
+		{"Root", `[]`, `true`, false},
+		{"IndexNumber", `["x", "y"]`, ``, true},
+		{"IndexObjectWithNumber", `["a", 0]`, ``, true},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			result, err := jv.Setpath(mustParse(t, tt.path), jq.JvFromBool(true))
+			if (err != nil) != tt.err {
+				t.Fatalf("Setpath() got error: %v, want error: %t", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if dump := result.Dump(jq.JvPrintNone); dump != tt.output {
+				t.Errorf("Setpath() got: %s, want: %s", dump, tt.output)
+			}
+		})
+	}
+
+	// The original value must be left untouched.
+	if dump := jv.Copy().Dump(jq.JvPrintNone); dump != `{"a":{"b":[10,20]},"n":null,"x":1}` {
+		t.Errorf("Setpath() modified the invocant: %s", dump)
+	}
+
+	_, err := jv.Setpath(mustParse(t, `["a", "b", "c"]`), jq.JvNull())
+	if want := `path component 2 ("c")`; err == nil || !strings.HasPrefix(err.Error(), want) {
+		t.Errorf("Setpath() got error: %v, want prefix: %s", err, want)
+	}
+}
+
+func TestJvGetPath(t *testing.T) {
+	jv := mustParse(t, `{"a": {"b": [10, 20]}, "n": null, "x": 1}`)
+	defer jv.Free()
+
+	table := []struct {
+		testName string
+		path     string
+		output   string
+		err      bool
+	}{
+		{"Empty", `[]`, `{"a":{"b":[10,20]},"n":null,"x":1}`, false},
+		{"Nested", `["a", "b", 1]`, `20`, false},
+		{"NegativeIndex", `["a", "b", -1]`, `20`, false},
+		{"StringIndex", `["a", "b", "1"]`, `20`, false},
+		{"Missing", `["a", "c"]`, `null`, false},
+		{"OutOfRange", `["a", "b", 5]`, `null`, false},
+		{"NegativeOutOfRange", `["a", "b", -3]`, `null`, false},
+		{"ThroughNull", `["n", "b", 0]`, `null`, false},
+		{"ThroughMissing", `["c", "d"]`, `null`, false},
+		{"IndexNumber", `["x", "y"]`, ``, true},
+		{"IndexArrayWithString", `["a", "b", "c"]`, ``, true},
+		{"IndexArrayWithLeadingZero", `["a", "b", "01"]`, ``, true},
+		{"IndexObjectWithNumber", `["a", 0]`, ``, true},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			result, err := jv.GetPath(mustParse(t, tt.path))
+			if (err != nil) != tt.err {
+				t.Fatalf("GetPath() got error: %v, want error: %t", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if dump := result.Dump(jq.JvPrintNone); dump != tt.output {
+				t.Errorf("GetPath() got: %s, want: %s", dump, tt.output)
+			}
+		})
+	}
+
+	_, err := jv.GetPath(mustParse(t, `["x", "y"]`))
+	if want := `path component 1 ("y")`; err == nil || !strings.HasPrefix(err.Error(), want) {
+		t.Errorf("GetPath() got error: %v, want prefix: %s", err, want)
+	}
+
+	if _, err := jv.GetPath(jq.JvFromString("a")); err == nil {
+		t.Errorf("GetPath() with a string path did not return an error")
+	}
+}
+
+func TestJvSetPath(t *testing.T) {
+	jv := mustParse(t, `{"a": {"b": [10, 20]}, "n": null, "x": 1}`)
+	defer jv.Free()
+
+	table := []struct {
+		testName string
+		path     string
+		output   string
+		err      bool
+	}{
+		{"Existing", `["a", "b", 0]`, `{"a":{"b":[true,20]},"n":null,"x":1}`, false},
+		{"NegativeIndex", `["a", "b", -1]`, `{"a":{"b":[10,true]},"n":null,"x":1}`, false},
+		{"StringIndex", `["a", "b", "0"]`, `{"a":{"b":[true,20]},"n":null,"x":1}`, false},
+		{"NewKey", `["a", "c"]`, `{"a":{"b":[10,20],"c":true},"n":null,"x":1}`, false},
+		{"NewNested", `["new", "deep", 1]`, `{"a":{"b":[10,20]},"n":null,"x":1,"new":{"deep":[null,true]}}`, false},
+		{"PastEnd", `["a", "b", 3]`, `{"a":{"b":[10,20,null,true]},"n":null,"x":1}`, false},
+		{"ThroughNull", `["n", "b"]`, `{"a":{"b":[10,20]},"n":{"b":true},"x":1}`, false},
+		{"Root", `[]`, `true`, false},
+		{"IndexNumber", `["x", "y"]`, ``, true},
+		{"IndexObjectWithNumber", `["a", 0]`, ``, true},
+		{"NegativeOutOfRange", `["a", "b", -3]`, ``, true},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			result, err := jv.SetPath(mustParse(t, tt.path), jq.JvFromBool(true))
+			if (err != nil) != tt.err {
+				t.Fatalf("SetPath() got error: %v, want error: %t", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if dump := result.Dump(jq.JvPrintNone); dump != tt.output {
+				t.Errorf("SetPath() got: %s, want: %s", dump, tt.output)
+			}
+		})
+	}
+
+	// The original value must be left untouched.
+	if dump := jv.Copy().Dump(jq.JvPrintNone); dump != `{"a":{"b":[10,20]},"n":null,"x":1}` {
+		t.Errorf("SetPath() modified the invocant: %s", dump)
+	}
+
+	_, err := jv.SetPath(mustParse(t, `["a", "b", "c"]`), jq.JvNull())
+	if want := `path component 2 ("c")`; err == nil || !strings.HasPrefix(err.Error(), want) {
+		t.Errorf("SetPath() got error: %v, want prefix: %s", err, want)
+	}
+}
+
+func TestJvErrorIfNullAndEmpty(t *testing.T) {
+	table := []struct {
+		testName string
+		input    string
+		null     bool
+		empty    bool
+	}{
+		{"Null", `null`, true, true},
+		{"EmptyArray", `[]`, false, true},
+		{"EmptyObject", `{}`, false, true},
+		{"EmptyString", `""`, false, true},
+		{"False", `false`, false, false},
+		{"Zero", `0`, false, false},
+		{"Array", `[null]`, false, false},
+		{"Object", `{"a": null}`, false, false},
+		{"String", `" "`, false, false},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			input := mustParse(t, tt.input)
+			defer input.Free()
+
+			result, err := input.ErrorIfNull("value is required")
+			if (err != nil) != tt.null {
+				t.Errorf("ErrorIfNull() got error: %v, want error: %t", err, tt.null)
+			} else if err != nil && err.Error() != "value is required" {
+				t.Errorf("ErrorIfNull() got error: %s, want: value is required", err)
+			} else if err == nil {
+				if dump := result.Dump(jq.JvPrintNone); dump != input.Copy().Dump(jq.JvPrintNone) {
+					t.Errorf("ErrorIfNull() got: %s, want the input unchanged", dump)
+				}
+			}
+
+			result, err = input.ErrorIfEmpty("value is required")
+			if (err != nil) != tt.empty {
+				t.Errorf("ErrorIfEmpty() got error: %v, want error: %t", err, tt.empty)
+			} else if err == nil {
+				result.Free()
+			}
+		})
+	}
+
+	invalid := jq.JvInvalid()
+	if _, err := invalid.ErrorIfNull("value is required"); err == nil {
+		t.Errorf("ErrorIfNull() on an invalid value did not return an error")
+	}
+	if _, err := invalid.ErrorIfEmpty("value is required"); err == nil {
+		t.Errorf("ErrorIfEmpty() on an invalid value did not return an error")
+	}
+}
+
+func TestJvFlatten(t *testing.T) {
+	input := mustParse(t, `[1, [2, [3, [4]]], [], "a"]`)
+	defer input.Free()
+
+	table := []struct {
+		depth  int
+		output string
+	}{
+		{-1, `[1,2,3,4,"a"]`},
+		{0, `[1,[2,[3,[4]]],[],"a"]`},
+		{1, `[1,2,[3,[4]],"a"]`},
+		{2, `[1,2,3,[4],"a"]`},
+		{10, `[1,2,3,4,"a"]`},
+	}
+
+	for _, tt := range table {
+		t.Run(fmt.Sprintf("Depth%d", tt.depth), func(t *testing.T) {
+			result, err := input.Flatten(tt.depth)
+			if err != nil {
+				t.Fatalf("Flatten() failed: %s", err)
+			}
+			if dump := result.Dump(jq.JvPrintNone); dump != tt.output {
+				t.Errorf("Flatten() got: %s, want: %s", dump, tt.output)
+			}
+		})
+	}
+
+	if _, err := input.Flatten(-2); err == nil {
+		t.Errorf("Flatten() with a depth of -2 did not return an error")
+	}
+
+	obj := mustParse(t, `{"a": [1]}`)
+	defer obj.Free()
+	if _, err := obj.Flatten(-1); err == nil {
+		t.Errorf("Flatten() on an object did not return an error")
+	}
+}
+
+func TestJvJSONMarshaling(t *testing.T) {
+	type record struct {
+		Name  string
+		Data  *jq.Jv
+		Extra *jq.Jv `json:",omitempty"`
+	}
+
+	table := []struct {
+		testName string
+		data     *jq.Jv
+		output   string
+	}{
+		{"Object", mustParse(t, `{"a": [1, "two", null]}`), `{"Name":"test","Data":{"a":[1,"two",null]}}`},
+		{"String", jq.JvFromString("hello"), `{"Name":"test","Data":"hello"}`},
+		{"Null", jq.JvNull(), `{"Name":"test","Data":null}`},
+		{"Invalid", jq.JvInvalid(), `{"Name":"test","Data":null}`},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			defer tt.data.Free()
+
+			b, err := json.Marshal(record{Name: "test", Data: tt.data})
+			if err != nil {
+				t.Fatalf("json.Marshal() failed: %s", err)
+			}
+			if string(b) != tt.output {
+				t.Errorf("json.Marshal() got: %s, want: %s", b, tt.output)
+			}
+			if !tt.data.IsValid() {
+				return
+			}
+
+			var decoded record
+			if err := json.Unmarshal(b, &decoded); err != nil {
+				t.Fatalf("json.Unmarshal() failed: %s", err)
+			}
+			if decoded.Name != "test" {
+				t.Errorf("json.Unmarshal() got Name: %s, want: test", decoded.Name)
+			}
+			if tt.data.Kind() == jq.JvKindNull {
+				// encoding/json leaves pointers nil for JSON null.
+				if decoded.Data != nil {
+					t.Errorf("json.Unmarshal() got Data: %s, want: nil", decoded.Data.Dump(jq.JvPrintNone))
+				}
+				return
+			}
+			if got, want := decoded.Data.Dump(jq.JvPrintNone), tt.data.Copy().Dump(jq.JvPrintNone); got != want {
+				t.Errorf("json.Unmarshal() got Data: %s, want: %s", got, want)
+			}
+		})
+	}
+
+	if err := json.Unmarshal([]byte(`{"Data": {"a": }`), &record{}); err == nil {
+		t.Errorf("json.Unmarshal() of invalid JSON did not return an error")
+	}
+}
+
+func TestJvSyncMap(t *testing.T) {
+	input := mustParse(t, `{"b": [1, 2], "a": "x", "c": null}`)
+	defer input.Free()
+
+	m := input.SyncMap()
+	if m == nil {
+		t.Fatalf("SyncMap() returned nil for an object")
+	}
+
+	value, ok := m.Load("b")
+	if !ok {
+		t.Fatalf("SyncMap() did not contain key b")
+	}
+	if dump := value.(*jq.Jv).Copy().Dump(jq.JvPrintNone); dump != `[1,2]` {
+		t.Errorf("SyncMap() got b: %s, want: [1,2]", dump)
+	}
+
+	m.Store("d", jq.JvFromBool(true))
+	result, err := jq.JvFromSyncMap(m)
+	if err != nil {
+		t.Fatalf("JvFromSyncMap() failed: %s", err)
+	}
+	if dump := result.Dump(jq.JvPrintNone); dump != `{"a":"x","b":[1,2],"c":null,"d":true}` {
+		t.Errorf("JvFromSyncMap() got: %s, want: %s", dump, `{"a":"x","b":[1,2],"c":null,"d":true}`)
+	}
+
+	m.Range(func(_, value interface{}) bool {
+		value.(*jq.Jv).Free()
+		return true
+	})
+
+	if jq.JvFromFloat(1).SyncMap() != nil {
+		t.Errorf("SyncMap() on a number did not return nil")
+	}
+
+	bad := new(sync.Map)
+	bad.Store(1, jq.JvNull())
+	if _, err := jq.JvFromSyncMap(bad); err == nil {
+		t.Errorf("JvFromSyncMap() with a non-string key did not return an error")
+	}
+	bad = new(sync.Map)
+	bad.Store("a", "not a jv")
+	if _, err := jq.JvFromSyncMap(bad); err == nil {
+		t.Errorf("JvFromSyncMap() with a non-*Jv value did not return an error")
+	}
+}
+
+func TestJvChan(t *testing.T) {
+	input := mustParse(t, `[1, "two", {"three": [3]}, null]`)
+	ch := input.Chan(0)
+	input.Free()
+
+	var values []interface{}
+	for value := range ch {
+		values = append(values, value)
+	}
+	if len(values) != 4 || values[0] != 1 || values[1] != "two" || values[3] != nil {
+		t.Fatalf("Chan() yielded: %v, want: [1 two map[three:[3]] <nil>]", values)
+	}
+
+	roundTripped := make(chan interface{}, len(values))
+	for _, value := range values {
+		roundTripped <- value
+	}
+	close(roundTripped)
+
+	result, err := jq.JvFromChan(roundTripped)
+	if err != nil {
+		t.Fatalf("JvFromChan() failed: %s", err)
+	}
+	if dump := result.Dump(jq.JvPrintNone); dump != `[1,"two",{"three":[3]},null]` {
+		t.Errorf("JvFromChan() got: %s, want: %s", dump, `[1,"two",{"three":[3]},null]`)
+	}
+
+	num := jq.JvFromFloat(1)
+	defer num.Free()
+	if _, ok := <-num.Chan(1); ok {
+		t.Errorf("Chan() on a number yielded a value")
+	}
+
+	bad := make(chan interface{}, 3)
+	bad <- 1
+	bad <- make(chan int)
+	bad <- 2
+	close(bad)
+	if _, err := jq.JvFromChan(bad); err == nil {
+		t.Errorf("JvFromChan() with an unsupported value did not return an error")
+	}
+	if len(bad) != 0 {
+		t.Errorf("JvFromChan() did not drain the channel after an error")
+	}
+}
+
+func TestJvTypedGet(t *testing.T) {
+	input := mustParse(t, `{"name": "jimmy", "age": 30, "admin": false, "email": null}`)
+	defer input.Free()
+
+	table := []struct {
+		testName string
+		key      string
+		kind     jq.JvKind
+		output   string
+		err      string
+	}{
+		{"String", "name", jq.JvKindString, `"jimmy"`, ""},
+		{"Number", "age", jq.JvKindNumber, `30`, ""},
+		{"Boolean", "admin", jq.JvKindTrue, `false`, ""},
+		{"Null", "email", jq.JvKindNull, `null`, ""},
+		{"WrongKind", "age", jq.JvKindString, ``, "expected field 'age' to be string, got number"},
+		{"NullIsWrongKind", "email", jq.JvKindString, ``, "expected field 'email' to be string, got null"},
+		{"Missing", "phone", jq.JvKindString, ``, "expected field 'phone' to be string, but it is missing"},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			result, err := input.TypedGet(tt.key, tt.kind)
+			if tt.err != "" {
+				if _, ok := err.(*jq.KindError); !ok || err.Error() != tt.err {
+					t.Errorf("TypedGet() got error: %v, want *KindError: %s", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("TypedGet() failed: %s", err)
+			}
+			if dump := result.Dump(jq.JvPrintNone); dump != tt.output {
+				t.Errorf("TypedGet() got: %s, want: %s", dump, tt.output)
+			}
+		})
+	}
+
+	ary := mustParse(t, `[]`)
+	defer ary.Free()
+	if _, err := ary.TypedGet("name", jq.JvKindString); err == nil {
+		t.Errorf("TypedGet() on an array did not return an error")
+	}
+}
+
+// byField returns a less function comparing the number in field of objects.
+func byField(field string) func(a, b *jq.Jv) bool {
+	return func(a, b *jq.Jv) bool {
+		av, _ := a.TypedGet(field, jq.JvKindNumber)
+		bv, _ := b.TypedGet(field, jq.JvKindNumber)
+		af, _ := av.ToFloat64()
+		bf, _ := bv.ToFloat64()
+		av.Free()
+		bv.Free()
+		return af < bf
+	}
+}
+
+func TestJvStableSort(t *testing.T) {
+	table := []struct {
+		testName string
+		input    string
+		less     func(a, b *jq.Jv) bool
+		output   string
+		err      bool
+	}{
+		{"Stable", `[{"a":2,"id":1},{"a":1,"id":2},{"a":2,"id":3},{"a":1,"id":4}]`, byField("a"), `[{"a":1,"id":2},{"a":1,"id":4},{"a":2,"id":1},{"a":2,"id":3}]`, false},
+		{"Empty", `[]`, byField("a"), `[]`, false},
+		{"NotArray", `{"a":1}`, byField("a"), ``, true},
+		{"Panic", `[1, 2]`, func(a, b *jq.Jv) bool { panic("boom") }, ``, true},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			input := mustParse(t, tt.input)
+			defer input.Free()
+
+			result, err := input.StableSort(tt.less)
+			if (err != nil) != tt.err {
+				t.Fatalf("StableSort() got error: %v, want error: %t", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if dump := result.Dump(jq.JvPrintNone); dump != tt.output {
+				t.Errorf("StableSort() got: %s, want: %s", dump, tt.output)
+			}
+			if dump := input.Copy().Dump(jq.JvPrintNone); dump != strings.Join(strings.Fields(tt.input), "") {
+				t.Errorf("StableSort() modified its input: %s", dump)
+			}
+		})
+	}
+}
+
+// benchmarkObjects returns an array of n objects with a pseudo-random number
+// in the field "a".
+func benchmarkObjects(n int) *jq.Jv {
+	ary := jq.JvArray()
+	for i := 0; i < n; i++ {
+		obj := jq.JvObject().ObjectSet(jq.JvFromString("a"), jq.JvFromFloat(float64(i*7919%n)))
+		ary = ary.ArrayAppend(obj)
+	}
+	return ary
+}
+
+func BenchmarkJvStableSort(b *testing.B) {
+	input := benchmarkObjects(10000)
+	defer input.Free()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		result, err := input.StableSort(byField("a"))
+		if err != nil {
+			b.Fatal(err)
+		}
+		result.Free()
+	}
+}
+
+func BenchmarkJqSortBy(b *testing.B) {
+	input := benchmarkObjects(10000)
+	defer input.Free()
+
+	libjq, err := jq.New()
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer libjq.Close()
+	for _, err := range libjq.Compile("sort_by(.a)", jq.JvArray()) {
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		results, err := libjq.Execute(input.Copy())
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, result := range results {
+			result.Free()
+		}
+	}
+}
+
+func TestJvTopN(t *testing.T) {
+	table := []struct {
+		testName string
+		input    string
+		n        int
+		top      string
+		bottom   string
+		err      bool
+	}{
+		{"Numbers", `[{"a":5},{"a":1},{"a":9},{"a":3},{"a":7}]`, 3, `[{"a":9},{"a":7},{"a":5}]`, `[{"a":1},{"a":3},{"a":5}]`, false},
+		{"MoreThanLength", `[{"a":2},{"a":1}]`, 5, `[{"a":2},{"a":1}]`, `[{"a":1},{"a":2}]`, false},
+		{"Zero", `[{"a":2},{"a":1}]`, 0, `[]`, `[]`, false},
+		{"Empty", `[]`, 3, `[]`, `[]`, false},
+		{"Negative", `[{"a":1}]`, -1, ``, ``, true},
+		{"NotArray", `{"a":1}`, 1, ``, ``, true},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			input := mustParse(t, tt.input)
+			defer input.Free()
+
+			top, err := input.TopN(tt.n, byField("a"))
+			if (err != nil) != tt.err {
+				t.Fatalf("TopN() got error: %v, want error: %t", err, tt.err)
+			}
+			bottom, err := input.BottomN(tt.n, byField("a"))
+			if (err != nil) != tt.err {
+				t.Fatalf("BottomN() got error: %v, want error: %t", err, tt.err)
+			}
+			if tt.err {
+				return
+			}
+
+			if dump := top.Dump(jq.JvPrintNone); dump != tt.top {
+				t.Errorf("TopN() got: %s, want: %s", dump, tt.top)
+			}
+			if dump := bottom.Dump(jq.JvPrintNone); dump != tt.bottom {
+				t.Errorf("BottomN() got: %s, want: %s", dump, tt.bottom)
+			}
+		})
+	}
+
+	input := mustParse(t, `[1, 2, 3]`)
+	defer input.Free()
+	if _, err := input.TopN(2, func(a, b *jq.Jv) bool { panic("boom") }); err == nil {
+		t.Errorf("TopN() with a panicking less did not return an error")
+	}
+
+	// less panics comparing an element against the root of a full heap, which
+	// must still be freed along with the heap.
+	objects := mustParse(t, `[{"a":1},{"a":2},{"a":3}]`)
+	defer objects.Free()
+	if _, err := objects.TopN(1, func(a, b *jq.Jv) bool { panic("boom") }); err == nil {
+		t.Errorf("TopN() with a panicking less did not return an error")
+	}
+	if dump := objects.Copy().Dump(jq.JvPrintRefCount); strings.Contains(dump, "(2)") {
+		t.Errorf("TopN() with a panicking less leaked elements: %s", dump)
+	}
+}
+
+func BenchmarkJvTopN(b *testing.B) {
+	input := benchmarkObjects(10000)
+	defer input.Free()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		result, err := input.TopN(10, byField("a"))
+		if err != nil {
+			b.Fatal(err)
+		}
+		result.Free()
+	}
+}
+
+func BenchmarkJvSortAndSlice(b *testing.B) {
+	input := benchmarkObjects(10000)
+	defer input.Free()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sorted, err := input.StableSort(func(x, y *jq.Jv) bool { return byField("a")(y, x) })
+		if err != nil {
+			b.Fatal(err)
+		}
+		top := jq.JvArray()
+		for j := 0; j < 10; j++ {
+			top = top.ArrayAppend(sorted.Copy().ArrayGet(j))
+		}
+		sorted.Free()
+		top.Free()
+	}
+}
+
+func TestJvDistinctBy(t *testing.T) {
+	field := func(name string) func(*jq.Jv) (*jq.Jv, error) {
+		return func(jv *jq.Jv) (*jq.Jv, error) {
+			return jv.GetPath(jq.JvFromStringSlice([]string{name}))
+		}
+	}
+
+	table := []struct {
+		testName string
+		input    string
+		fn       func(*jq.Jv) (*jq.Jv, error)
+		output   string
+		err      bool
+	}{
+		{"Scalars", `[{"a":1,"id":1},{"a":2,"id":2},{"a":1,"id":3}]`, field("a"), `[{"a":1,"id":1},{"a":2,"id":2}]`, false},
+		{"Objects", `[{"k":{"x":1,"y":2},"id":1},{"k":{"y":2,"x":1},"id":2},{"k":{"x":2},"id":3}]`, field("k"), `[{"k":{"x":1,"y":2},"id":1},{"k":{"x":2},"id":3}]`, false},
+		{"Nulls", `[{"id":1},{"a":null,"id":2},{"a":0,"id":3}]`, field("a"), `[{"id":1},{"a":0,"id":3}]`, false},
+		{"AlwaysNull", `[1,2,3]`, func(*jq.Jv) (*jq.Jv, error) { return jq.JvNull(), nil }, `[1]`, false},
+		{"NilKey", `[1,null,2]`, func(jv *jq.Jv) (*jq.Jv, error) {
+			if jv.Kind() == jq.JvKindNumber {
+				return nil, nil
+			}
+			return jq.JvNull(), nil
+		}, `[1]`, false},
+		{"Empty", `[]`, field("a"), `[]`, false},
+		{"Error", `[{"a":1}]`, func(*jq.Jv) (*jq.Jv, error) { return nil, errors.New("no key") }, ``, true},
+		{"KeyAndError", `[{"a":1}]`, func(*jq.Jv) (*jq.Jv, error) { return jq.JvNull(), errors.New("no key") }, ``, true},
+		{"NotArray", `{"a":1}`, field("a"), ``, true},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			input := mustParse(t, tt.input)
+			defer input.Free()
+
+			result, err := input.DistinctBy(tt.fn)
+			if (err != nil) != tt.err {
+				t.Fatalf("DistinctBy() got error: %v, want error: %t", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if dump := result.Dump(jq.JvPrintNone); dump != tt.output {
+				t.Errorf("DistinctBy() got: %s, want: %s", dump, tt.output)
+			}
+		})
+	}
+}
+
+func TestJvKeysValues(t *testing.T) {
+	dumpAll := func(jvs []*jq.Jv) string {
+		var dumps []string
+		for _, jv := range jvs {
+			dumps = append(dumps, jv.Dump(jq.JvPrintNone))
+		}
+		return strings.Join(dumps, " ")
+	}
+
+	table := []struct {
+		testName string
+		input    string
+		keys     string
+		values   string
+		err      bool
+	}{
+		{"Object", `{"b": 2, "a": [1], "c": null}`, `"a" "b" "c"`, `[1] 2 null`, false},
+		{"Array", `["x", {"y": 1}]`, `0 1`, `"x" {"y":1}`, false},
+		{"EmptyObject", `{}`, ``, ``, false},
+		{"EmptyArray", `[]`, ``, ``, false},
+		{"Scalar", `"abc"`, ``, ``, true},
+		{"Null", `null`, ``, ``, true},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			input := mustParse(t, tt.input)
+			defer input.Free()
+
+			keys, err := input.Keys()
+			if tt.err {
+				if _, ok := err.(*jq.KindError); !ok {
+					t.Errorf("Keys() got error: %v, want *KindError", err)
+				}
+			} else if dump := dumpAll(keys); err != nil || dump != tt.keys {
+				t.Errorf("Keys() got: %s (%v), want: %s", dump, err, tt.keys)
+			}
+
+			values, err := input.Values()
+			if tt.err {
+				if _, ok := err.(*jq.KindError); !ok {
+					t.Errorf("Values() got error: %v, want *KindError", err)
+				}
+			} else if dump := dumpAll(values); err != nil || dump != tt.values {
+				t.Errorf("Values() got: %s (%v), want: %s", dump, err, tt.values)
+			}
+		})
+	}
+}
+
+func TestJvInterleave(t *testing.T) {
+	table := []struct {
+		testName string
+		input    string
+		other    string
+		output   string
+		err      bool
+	}{
+		{"EqualLength", `["a","b","c"]`, `["x","y","z"]`, `["a","x","b","y","c","z"]`, false},
+		{"ShorterOther", `[1,2,3]`, `[4]`, `[1,4,2,null,3,null]`, false},
+		{"ShorterInput", `[1]`, `[4,5]`, `[1,4,null,5]`, false},
+		{"BothEmpty", `[]`, `[]`, `[]`, false},
+		{"OneEmpty", `[]`, `[1,2]`, `[null,1,null,2]`, false},
+		{"NotArray", `{"a":1}`, `[1]`, ``, true},
+		{"OtherNotArray", `[1]`, `"x"`, ``, true},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			input := mustParse(t, tt.input)
+			defer input.Free()
+
+			result, err := input.Interleave(mustParse(t, tt.other))
+			if (err != nil) != tt.err {
+				t.Fatalf("Interleave() got error: %v, want error: %t", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if dump := result.Dump(jq.JvPrintNone); dump != tt.output {
+				t.Errorf("Interleave() got: %s, want: %s", dump, tt.output)
+			}
+		})
+	}
+}
+
+func TestJvRotate(t *testing.T) {
+	table := []struct {
+		testName string
+		input    string
+		n        int
+		left     string
+		right    string
+	}{
+		{"Zero", `[1,2,3,4]`, 0, `[1,2,3,4]`, `[1,2,3,4]`},
+		{"One", `[1,2,3,4]`, 1, `[2,3,4,1]`, `[4,1,2,3]`},
+		{"FullRotation", `[1,2,3,4]`, 4, `[1,2,3,4]`, `[1,2,3,4]`},
+		{"LargerThanLength", `[1,2,3,4]`, 6, `[3,4,1,2]`, `[3,4,1,2]`},
+		{"Negative", `[1,2,3,4]`, -1, `[4,1,2,3]`, `[2,3,4,1]`},
+		{"NegativeLargerThanLength", `[1,2,3,4]`, -5, `[4,1,2,3]`, `[2,3,4,1]`},
+		{"Empty", `[]`, 3, `[]`, `[]`},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			input := mustParse(t, tt.input)
+			defer input.Free()
+
+			left, err := input.RotateLeft(tt.n)
+			if err != nil {
+				t.Fatalf("RotateLeft() failed: %s", err)
+			}
+			if dump := left.Dump(jq.JvPrintNone); dump != tt.left {
+				t.Errorf("RotateLeft() got: %s, want: %s", dump, tt.left)
+			}
+
+			right, err := input.RotateRight(tt.n)
+			if err != nil {
+				t.Fatalf("RotateRight() failed: %s", err)
+			}
+			if dump := right.Dump(jq.JvPrintNone); dump != tt.right {
+				t.Errorf("RotateRight() got: %s, want: %s", dump, tt.right)
+			}
+		})
+	}
+
+	obj := mustParse(t, `{"a":1}`)
+	defer obj.Free()
+	if _, err := obj.RotateLeft(1); err == nil {
+		t.Errorf("RotateLeft() on an object did not return an error")
+	}
+	if _, err := obj.RotateRight(1); err == nil {
+		t.Errorf("RotateRight() on an object did not return an error")
+	}
+}
+
+func TestJvDeduplicate(t *testing.T) {
+	table := []struct {
+		testName string
+		input    string
+		output   string
+		err      bool
+	}{
+		{"KeepsFirstOccurrenceOrder", `[3,1,3,2,1]`, `[3,1,2]`, false},
+		{"Strings", `["b","a","b","c","a"]`, `["b","a","c"]`, false},
+		{"Objects", `[{"x":1,"y":2},{"z":0},{"y":2,"x":1}]`, `[{"x":1,"y":2},{"z":0}]`, false},
+		{"Mixed", `[null,1,"1",[1],1,null]`, `[null,1,"1",[1]]`, false},
+		{"Empty", `[]`, `[]`, false},
+		{"NotArray", `"abc"`, ``, true},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			input := mustParse(t, tt.input)
+			defer input.Free()
+
+			result, err := input.Deduplicate()
+			if (err != nil) != tt.err {
+				t.Fatalf("Deduplicate() got error: %v, want error: %t", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if dump := result.Dump(jq.JvPrintNone); dump != tt.output {
+				t.Errorf("Deduplicate() got: %s, want: %s", dump, tt.output)
+			}
+		})
+	}
+}
+
+func TestJvAllAny(t *testing.T) {
+	calls := 0
+	positive := func(jv *jq.Jv) (bool, error) {
+		calls++
+		if jv.Kind() != jq.JvKindNumber {
+			return false, fmt.Errorf("not a number: %s", jv.Copy().Dump(jq.JvPrintNone))
+		}
+		f, _ := jv.ToFloat64()
+		return f > 0, nil
+	}
+
+	table := []struct {
+		testName string
+		input    string
+		all      bool
+		allCalls int
+		any      bool
+		anyCalls int
+		err      bool
+	}{
+		{"AllTrue", `[1,2,3]`, true, 3, true, 1, false},
+		{"AllFalse", `[-1,-2,-3]`, false, 1, false, 3, false},
+		{"Mixed", `[-1,2,-3]`, false, 1, true, 2, false},
+		{"Empty", `[]`, true, 0, false, 0, false},
+		{"Error", `["x",1]`, false, 1, false, 1, true},
+		{"NotArray", `{"a":1}`, false, 0, false, 0, true},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			input := mustParse(t, tt.input)
+			defer input.Free()
+
+			calls = 0
+			all, err := input.All(positive)
+			if (err != nil) != tt.err {
+				t.Fatalf("All() got error: %v, want error: %t", err, tt.err)
+			}
+			if all != tt.all || calls != tt.allCalls {
+				t.Errorf("All() got: %t after %d calls, want: %t after %d calls", all, calls, tt.all, tt.allCalls)
+			}
+
+			calls = 0
+			any, err := input.Any(positive)
+			if (err != nil) != tt.err {
+				t.Fatalf("Any() got error: %v, want error: %t", err, tt.err)
+			}
+			if any != tt.any || calls != tt.anyCalls {
+				t.Errorf("Any() got: %t after %d calls, want: %t after %d calls", any, calls, tt.any, tt.anyCalls)
+			}
+		})
+	}
+}
+
+func TestJvNone(t *testing.T) {
+	calls := 0
+	isNull := func(jv *jq.Jv) (bool, error) {
+		calls++
+		if jv.Kind() == jq.JvKindObject {
+			return false, errors.New("unexpected object")
+		}
+		return jv.Kind() == jq.JvKindNull, nil
+	}
+
+	table := []struct {
+		testName string
+		input    string
+		none     bool
+		calls    int
+		err      bool
+	}{
+		{"FirstMatches", `[null,1,2]`, false, 1, false},
+		{"LastMatches", `[1,2,null]`, false, 3, false},
+		{"NoneMatch", `[1,"a",[]]`, true, 3, false},
+		{"Empty", `[]`, true, 0, false},
+		{"Error", `[1,{},null]`, false, 2, true},
+		{"NotArray", `"abc"`, false, 0, true},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			input := mustParse(t, tt.input)
+			defer input.Free()
+
+			calls = 0
+			none, err := input.None(isNull)
+			if (err != nil) != tt.err {
+				t.Fatalf("None() got error: %v, want error: %t", err, tt.err)
+			}
+			if none != tt.none || calls != tt.calls {
+				t.Errorf("None() got: %t after %d calls, want: %t after %d calls", none, calls, tt.none, tt.calls)
+			}
+		})
+	}
+}
+
+func TestJvCountBy(t *testing.T) {
+	isString := func(jv *jq.Jv) (bool, error) {
+		if jv.Kind() == jq.JvKindObject {
+			return false, errors.New("unexpected object")
+		}
+		return jv.Kind() == jq.JvKindString, nil
+	}
+
+	table := []struct {
+		testName string
+		input    string
+		count    int
+		err      bool
+	}{
+		{"NoneMatch", `[1,null,[]]`, 0, false},
+		{"SomeMatch", `["a",1,"b",null]`, 2, false},
+		{"AllMatch", `["a","b","c"]`, 3, false},
+		{"Empty", `[]`, 0, false},
+		{"Error", `["a",{}]`, 0, true},
+		{"NotArray", `{"a":"b"}`, 0, true},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			input := mustParse(t, tt.input)
+			defer input.Free()
+
+			count, err := input.CountBy(isString)
+			if (err != nil) != tt.err {
+				t.Fatalf("CountBy() got error: %v, want error: %t", err, tt.err)
+			}
+			if count != tt.count {
+				t.Errorf("CountBy() got: %d, want: %d", count, tt.count)
+			}
+		})
+	}
+}
+
+func TestJvAggregates(t *testing.T) {
+	price := func(jv *jq.Jv) (float64, error) {
+		value, err := jv.TypedGet("price", jq.JvKindNumber)
+		if err != nil {
+			return 0, err
+		}
+		defer value.Free()
+		return value.ToFloat64()
+	}
+
+	table := []struct {
+		testName string
+		input    string
+		sum      float64
+		min      float64
+		max      float64
+		average  float64
+		err      bool
+		emptyErr bool
+	}{
+		{"Numbers", `[{"price":3},{"price":1.5},{"price":-0.5},{"price":4}]`, 8, -0.5, 4, 2, false, false},
+		{"Single", `[{"price":7}]`, 7, 7, 7, 7, false, false},
+		{"Empty", `[]`, 0, 0, 0, 0, false, true},
+		{"NotNumber", `[{"price":1},{"price":"2"}]`, 0, 0, 0, 0, true, true},
+		{"NotArray", `{"price":1}`, 0, 0, 0, 0, true, true},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			input := mustParse(t, tt.input)
+			defer input.Free()
+
+			sum, err := input.SumBy(price)
+			if (err != nil) != tt.err || sum != tt.sum {
+				t.Errorf("SumBy() got: %v (%v), want: %v", sum, err, tt.sum)
+			}
+
+			for _, agg := range []struct {
+				name string
+				fn   func(func(*jq.Jv) (float64, error)) (float64, error)
+				want float64
+			}{
+				{"MinVal", input.MinVal, tt.min},
+				{"MaxVal", input.MaxVal, tt.max},
+				{"Average", input.Average, tt.average},
+			} {
+				got, err := agg.fn(price)
+				if (err != nil) != tt.emptyErr {
+					t.Errorf("%s() got error: %v, want error: %t", agg.name, err, tt.emptyErr)
+				} else if got != agg.want {
+					t.Errorf("%s() got: %v, want: %v", agg.name, got, agg.want)
+				}
+			}
+		})
+	}
+}
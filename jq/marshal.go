@@ -0,0 +1,61 @@
+// Copyright (c) 2018 Jimmy Zelinskie
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package jq
+
+import (
+	"bytes"
+
+	"github.com/BurntSushi/toml"
+	"github.com/ghodss/yaml"
+)
+
+// ToYAML serializes jv as a YAML document by way of ToGoVal.
+//
+// Consumes the invocant.
+func (jv *Jv) ToYAML() (string, error) {
+	defer jv.Free()
+
+	b, err := yaml.Marshal(jv.ToGoVal())
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// ToTOML serializes jv as a TOML document by way of ToGoVal. TOML documents
+// are tables, so jv must be an object.
+//
+// Returns a *KindError if jv is not an object.
+//
+// Consumes the invocant.
+func (jv *Jv) ToTOML() (string, error) {
+	defer jv.Free()
+
+	if jv.Kind() != JvKindObject {
+		return "", &KindError{Op: "ToTOML", Kind: jv.Kind()}
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(jv.ToGoVal()); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2018 Jimmy Zelinskie
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package jq_test
+
+import "testing"
+
+func TestJvToYAML(t *testing.T) {
+	table := []struct {
+		testName string
+		input    string
+		output   string
+	}{
+		{"Object", `{"name": "faq", "tags": ["a", "b"], "ratio": 1.5}`, "name: faq\nratio: 1.5\ntags:\n- a\n- b\n"},
+		{"Nested", `{"a": {"b": null}}`, "a:\n  b: null\n"},
+		{"Scalar", `"faq"`, "faq\n"},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			output, err := mustParse(t, tt.input).ToYAML()
+			if err != nil {
+				t.Fatalf("ToYAML() failed: %s", err)
+			}
+			if output != tt.output {
+				t.Errorf("ToYAML() got: %q, want: %q", output, tt.output)
+			}
+		})
+	}
+}
+
+func TestJvToTOML(t *testing.T) {
+	output, err := mustParse(t, `{"name": "faq", "server": {"port": 8080}}`).ToTOML()
+	if err != nil {
+		t.Fatalf("ToTOML() failed: %s", err)
+	}
+	if want := "name = \"faq\"\n\n[server]\n  port = 8080\n"; output != want {
+		t.Errorf("ToTOML() got: %q, want: %q", output, want)
+	}
+
+	if _, err := mustParse(t, `[1, 2]`).ToTOML(); err == nil {
+		t.Errorf("ToTOML() on an array did not return an error")
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2018 Jimmy Zelinskie
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package jq
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// JvFromOAuth2Token returns a new object-typed jv holding the access token,
+// token type, refresh token and expiry of token, using the same keys as its
+// JSON encoding. The expiry is formatted as RFC 3339 with a precision of one
+// second. Empty fields are left out, and a nil token is converted to null.
+func JvFromOAuth2Token(token *oauth2.Token) *Jv {
+	if token == nil {
+		return JvNull()
+	}
+
+	obj := JvObject().ObjectSet(JvFromString("access_token"), JvFromString(token.AccessToken))
+	if token.TokenType != "" {
+		obj = obj.ObjectSet(JvFromString("token_type"), JvFromString(token.TokenType))
+	}
+	if token.RefreshToken != "" {
+		obj = obj.ObjectSet(JvFromString("refresh_token"), JvFromString(token.RefreshToken))
+	}
+	if !token.Expiry.IsZero() {
+		obj = obj.ObjectSet(JvFromString("expiry"), JvFromString(token.Expiry.Format(time.RFC3339)))
+	}
+	return obj
+}
+
+// ToOAuth2Token converts an object-typed jv, such as one returned by
+// JvFromOAuth2Token, into an OAuth2 token. Only the access_token field is
+// required, and the expiry must be formatted as RFC 3339.
+//
+// Returns a *KindError if jv is not an object or one of its fields is of the
+// wrong kind, or an error if the expiry can't be parsed.
+//
+// Does not consume the invocant.
+func (jv *Jv) ToOAuth2Token() (*oauth2.Token, error) {
+	if jv.Kind() != JvKindObject {
+		return nil, &KindError{Op: "ToOAuth2Token", Kind: jv.Kind()}
+	}
+
+	accessToken, err := jv.TypedGet("access_token", JvKindString)
+	if err != nil {
+		return nil, err
+	}
+	token := &oauth2.Token{AccessToken: accessToken._string()}
+	accessToken.Free()
+
+	if token.TokenType, err = jv.optionalString("token_type"); err != nil {
+		return nil, err
+	}
+	if token.RefreshToken, err = jv.optionalString("refresh_token"); err != nil {
+		return nil, err
+	}
+
+	expiry, err := jv.optionalString("expiry")
+	if err != nil {
+		return nil, err
+	}
+	if expiry != "" {
+		if token.Expiry, err = time.Parse(time.RFC3339, expiry); err != nil {
+			return nil, fmt.Errorf("failed to parse expiry: %s", err)
+		}
+	}
+
+	return token, nil
+}
+
+// optionalString returns the value of the field key of an object-typed jv if
+// it is a string, or "" if it is missing or null.
+//
+// Does not consume the invocant.
+func (jv *Jv) optionalString(key string) (string, error) {
+	value, err := jv.TypedGet(key, JvKindString)
+	if err == nil {
+		defer value.Free()
+		return value._string(), nil
+	}
+
+	if kindErr, ok := err.(*KindError); ok && (kindErr.Kind == JvKindInvalid || kindErr.Kind == JvKindNull) {
+		return "", nil
+	}
+	return "", err
+}
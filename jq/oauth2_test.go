@@ -0,0 +1,105 @@
+// Copyright (c) 2018 Jimmy Zelinskie
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package jq_test
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"github.com/jzelinskie/faq/jq"
+)
+
+func TestJvOAuth2Token(t *testing.T) {
+	expiry := time.Date(2024, time.March, 1, 12, 30, 45, 123456789, time.FixedZone("CET", 3600))
+	token := &oauth2.Token{
+		AccessToken:  "access",
+		TokenType:    "Bearer",
+		RefreshToken: "refresh",
+		Expiry:       expiry,
+	}
+
+	jv := jq.JvFromOAuth2Token(token)
+	defer jv.Free()
+
+	want := `{"access_token":"access","token_type":"Bearer","refresh_token":"refresh","expiry":"2024-03-01T12:30:45+01:00"}`
+	if got := jv.Copy().Dump(jq.JvPrintNone); got != want {
+		t.Errorf("JvFromOAuth2Token() got: %s, want: %s", got, want)
+	}
+
+	parsed, err := jv.ToOAuth2Token()
+	if err != nil {
+		t.Fatalf("ToOAuth2Token() failed: %s", err)
+	}
+	if parsed.AccessToken != token.AccessToken || parsed.TokenType != token.TokenType || parsed.RefreshToken != token.RefreshToken {
+		t.Errorf("ToOAuth2Token() got: %+v, want: %+v", parsed, token)
+	}
+	if diff := parsed.Expiry.Sub(expiry); diff < -time.Second || diff > time.Second {
+		t.Errorf("ToOAuth2Token() got expiry: %s, want: %s", parsed.Expiry, expiry)
+	}
+}
+
+func TestJvOAuth2TokenMinimal(t *testing.T) {
+	jv := jq.JvFromOAuth2Token(&oauth2.Token{AccessToken: "access"})
+	defer jv.Free()
+
+	if got, want := jv.Copy().Dump(jq.JvPrintNone), `{"access_token":"access"}`; got != want {
+		t.Errorf("JvFromOAuth2Token() got: %s, want: %s", got, want)
+	}
+
+	parsed, err := jv.ToOAuth2Token()
+	if err != nil {
+		t.Fatalf("ToOAuth2Token() failed: %s", err)
+	}
+	if parsed.AccessToken != "access" || parsed.TokenType != "" || parsed.RefreshToken != "" || !parsed.Expiry.IsZero() {
+		t.Errorf("ToOAuth2Token() got: %+v", parsed)
+	}
+}
+
+func TestJvToOAuth2TokenErrors(t *testing.T) {
+	table := []struct {
+		testName string
+		input    string
+	}{
+		{"NotObject", `"access"`},
+		{"MissingAccessToken", `{"token_type":"Bearer"}`},
+		{"WrongKind", `{"access_token":"access","refresh_token":1}`},
+		{"InvalidExpiry", `{"access_token":"access","expiry":"tomorrow"}`},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			input := mustParse(t, tt.input)
+			defer input.Free()
+
+			if token, err := input.ToOAuth2Token(); err == nil {
+				t.Errorf("ToOAuth2Token() got: %+v, want an error", token)
+			}
+		})
+	}
+}
+
+func TestJvFromOAuth2TokenNil(t *testing.T) {
+	if kind := jq.JvFromOAuth2Token(nil).Kind(); kind != jq.JvKindNull {
+		t.Errorf("JvFromOAuth2Token(nil) got kind: %s, want: null", kind)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2018 Jimmy Zelinskie
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package jq
+
+import (
+	"github.com/iancoleman/orderedmap"
+)
+
+// ToOrderedMap converts an object-typed jv into an ordered map, keeping the
+// order of its keys, or returns nil if jv is not an object. Nested objects,
+// including those within arrays, are converted into *orderedmap.OrderedMap
+// and every other value with ToGoVal.
+//
+// Does not consume the invocant.
+func (jv *Jv) ToOrderedMap() *orderedmap.OrderedMap {
+	if jv.Kind() != JvKindObject {
+		return nil
+	}
+
+	m := orderedmap.New()
+	jv.ObjectForEach(func(key string, value *Jv) error {
+		m.Set(key, value.toOrderedGoVal())
+		return nil
+	})
+	return m
+}
+
+// toOrderedGoVal is ToGoVal, but converts objects with ToOrderedMap.
+//
+// Does not consume the invocant.
+func (jv *Jv) toOrderedGoVal() interface{} {
+	switch jv.Kind() {
+	case JvKindObject:
+		return jv.ToOrderedMap()
+	case JvKindArray:
+		len := jv.Copy().ArrayLength()
+		ary := make([]interface{}, len)
+		for i := 0; i < len; i++ {
+			elem := jv.Copy().ArrayGet(i)
+			ary[i] = elem.toOrderedGoVal()
+			elem.Free()
+		}
+		return ary
+	default:
+		return jv.ToGoVal()
+	}
+}
+
+// JvFromOrderedMap returns a new object-typed jv holding the entries of m in
+// its order. Values that are ordered maps, either as values or pointers, are
+// converted in the same way, as are those within slices, and every other
+// value is converted with JvFromInterface.
+func JvFromOrderedMap(m *orderedmap.OrderedMap) (*Jv, error) {
+	obj := JvObject()
+	for _, key := range m.Keys() {
+		value, _ := m.Get(key)
+		valueJv, err := jvFromOrderedValue(value)
+		if err != nil {
+			obj.Free()
+			return nil, err
+		}
+		obj = obj.ObjectSet(JvFromString(key), valueJv)
+	}
+	return obj, nil
+}
+
+// jvFromOrderedValue converts a value held by an ordered map into a Jv.
+func jvFromOrderedValue(value interface{}) (*Jv, error) {
+	switch v := value.(type) {
+	case *orderedmap.OrderedMap:
+		if v == nil {
+			return JvNull(), nil
+		}
+		return JvFromOrderedMap(v)
+	case orderedmap.OrderedMap:
+		return JvFromOrderedMap(&v)
+	case []interface{}:
+		ary := JvArray()
+		for _, elem := range v {
+			elemJv, err := jvFromOrderedValue(elem)
+			if err != nil {
+				ary.Free()
+				return nil, err
+			}
+			ary = ary.ArrayAppend(elemJv)
+		}
+		return ary, nil
+	default:
+		return JvFromInterface(value)
+	}
+}
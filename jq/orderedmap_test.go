@@ -0,0 +1,93 @@
+// Copyright (c) 2018 Jimmy Zelinskie
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package jq_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/iancoleman/orderedmap"
+
+	"github.com/jzelinskie/faq/jq"
+)
+
+func TestJvOrderedMap(t *testing.T) {
+	table := []struct {
+		testName string
+		input    string
+	}{
+		{"Empty", `{}`},
+		{"Ordered", `{"z":1,"a":2,"m":3}`},
+		{"Nested", `{"z":{"y":true,"b":null},"a":[{"q":"s","c":1.5},[{"x":1,"w":2}]]}`},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			input := mustParse(t, tt.input)
+			defer input.Free()
+
+			m := input.ToOrderedMap()
+			if m == nil {
+				t.Fatal("ToOrderedMap() returned nil")
+			}
+			b, err := json.Marshal(m)
+			if err != nil {
+				t.Fatalf("failed to marshal ordered map: %s", err)
+			}
+			if string(b) != tt.input {
+				t.Errorf("ToOrderedMap() got: %s, want: %s", b, tt.input)
+			}
+
+			output, err := jq.JvFromOrderedMap(m)
+			if err != nil {
+				t.Fatalf("JvFromOrderedMap() failed: %s", err)
+			}
+			if got := output.Dump(jq.JvPrintNone); got != tt.input {
+				t.Errorf("JvFromOrderedMap() got: %s, want: %s", got, tt.input)
+			}
+		})
+	}
+}
+
+func TestJvFromUnmarshaledOrderedMap(t *testing.T) {
+	input := `{"z":{"y":true,"b":null},"a":[{"q":"s","c":1.5}]}`
+	m := orderedmap.New()
+	if err := json.Unmarshal([]byte(input), m); err != nil {
+		t.Fatalf("failed to unmarshal ordered map: %s", err)
+	}
+
+	output, err := jq.JvFromOrderedMap(m)
+	if err != nil {
+		t.Fatalf("JvFromOrderedMap() failed: %s", err)
+	}
+	if got := output.Dump(jq.JvPrintNone); got != input {
+		t.Errorf("JvFromOrderedMap() got: %s, want: %s", got, input)
+	}
+}
+
+func TestJvToOrderedMapNotObject(t *testing.T) {
+	input := mustParse(t, `[1,2]`)
+	defer input.Free()
+
+	if m := input.ToOrderedMap(); m != nil {
+		t.Errorf("ToOrderedMap() got: %v, want: nil", m)
+	}
+}
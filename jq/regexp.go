@@ -0,0 +1,288 @@
+// Copyright (c) 2018 Jimmy Zelinskie
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package jq
+
+import (
+	"container/list"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// maxCachedRegexps bounds the number of compiled patterns kept by
+// regexpCache.
+const maxCachedRegexps = 128
+
+// cachedRegexp is a compiled pattern held in regexpCache.
+type cachedRegexp struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+// regexpCache maps regular expression patterns to their compiled form so that
+// the regular expression helpers only pay the cost of compilation once. The
+// least recently used patterns are evicted once there are more than
+// maxCachedRegexps.
+var regexpCache = struct {
+	sync.Mutex
+	regexps map[string]*list.Element
+	lru     *list.List
+}{regexps: make(map[string]*list.Element), lru: list.New()}
+
+// compileCached compiles pattern with the regexp package, reusing the result
+// of any previous compilation of the same pattern.
+func compileCached(pattern string) (*regexp.Regexp, error) {
+	regexpCache.Lock()
+	if elem, ok := regexpCache.regexps[pattern]; ok {
+		regexpCache.lru.MoveToFront(elem)
+		regexpCache.Unlock()
+		return elem.Value.(*cachedRegexp).re, nil
+	}
+	regexpCache.Unlock()
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	regexpCache.Lock()
+	defer regexpCache.Unlock()
+
+	// Another goroutine may have compiled the same pattern in the meantime.
+	if elem, ok := regexpCache.regexps[pattern]; ok {
+		regexpCache.lru.MoveToFront(elem)
+		return elem.Value.(*cachedRegexp).re, nil
+	}
+
+	regexpCache.regexps[pattern] = regexpCache.lru.PushFront(&cachedRegexp{pattern: pattern, re: re})
+	if regexpCache.lru.Len() > maxCachedRegexps {
+		oldest := regexpCache.lru.Remove(regexpCache.lru.Back()).(*cachedRegexp)
+		delete(regexpCache.regexps, oldest.pattern)
+	}
+	return re, nil
+}
+
+// Test reports whether a string-typed jv contains a match of the regular
+// expression pattern, like jq's `test(re)`.
+//
+// Patterns use the syntax of Go's regexp package rather than jq's Oniguruma,
+// although both accept the common subset including named groups written as
+// (?<name>re). Compiled patterns are cached.
+//
+// Returns a *KindError if jv is not a string, or an error if pattern is not a
+// valid regular expression.
+//
+// Does not consume the invocant.
+func (jv *Jv) Test(pattern string) (bool, error) {
+	str, err := jv.StringValue()
+	if err != nil {
+		return false, &KindError{Op: "Test", Kind: jv.Kind()}
+	}
+
+	re, err := compileCached(pattern)
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(str), nil
+}
+
+// Capture returns an object mapping the names of the named groups of pattern
+// to the text they matched in the first match within a string-typed jv, like
+// jq's `capture(re)`. Groups that did not participate in the match are null.
+//
+// If pattern does not match, a null jv is returned.
+//
+// Patterns use the syntax of Go's regexp package; see Test. Compiled
+// patterns are cached.
+//
+// Returns a *KindError if jv is not a string, or an error if pattern is not a
+// valid regular expression.
+//
+// Does not consume the invocant.
+func (jv *Jv) Capture(pattern string) (*Jv, error) {
+	str, err := jv.StringValue()
+	if err != nil {
+		return nil, &KindError{Op: "Capture", Kind: jv.Kind()}
+	}
+
+	re, err := compileCached(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	match := re.FindStringSubmatchIndex(str)
+	if match == nil {
+		return JvNull(), nil
+	}
+	return namedCaptures(re, str, match), nil
+}
+
+// namedCaptures builds an object of the named groups of re from the submatch
+// indices of a single match in str.
+func namedCaptures(re *regexp.Regexp, str string, match []int) *Jv {
+	captures := JvObject()
+	for i, name := range re.SubexpNames() {
+		if name == "" {
+			continue
+		}
+
+		value := JvNull()
+		if start, end := match[2*i], match[2*i+1]; start >= 0 {
+			value = JvFromString(str[start:end])
+		}
+		captures = captures.ObjectSet(JvFromString(name), value)
+	}
+	return captures
+}
+
+// Scan returns every non-overlapping match of the regular expression pattern
+// in a string-typed jv as string-typed jvs, like jq's `scan(re)` for patterns
+// without groups.
+//
+// Patterns use the syntax of Go's regexp package; see Test. Compiled
+// patterns are cached.
+//
+// Returns a *KindError if jv is not a string, or an error if pattern is not a
+// valid regular expression.
+//
+// Does not consume the invocant.
+func (jv *Jv) Scan(pattern string) ([]*Jv, error) {
+	str, err := jv.StringValue()
+	if err != nil {
+		return nil, &KindError{Op: "Scan", Kind: jv.Kind()}
+	}
+
+	re, err := compileCached(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := re.FindAllString(str, -1)
+	results := make([]*Jv, len(matches))
+	for i, match := range matches {
+		results[i] = JvFromString(match)
+	}
+	return results, nil
+}
+
+// ScanCaptures returns an object of the named groups of pattern for every
+// non-overlapping match in a string-typed jv, in the same form as Capture.
+//
+// Patterns use the syntax of Go's regexp package; see Test. Compiled
+// patterns are cached.
+//
+// Returns a *KindError if jv is not a string, or an error if pattern is not a
+// valid regular expression.
+//
+// Does not consume the invocant.
+func (jv *Jv) ScanCaptures(pattern string) ([]*Jv, error) {
+	str, err := jv.StringValue()
+	if err != nil {
+		return nil, &KindError{Op: "ScanCaptures", Kind: jv.Kind()}
+	}
+
+	re, err := compileCached(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := re.FindAllStringSubmatchIndex(str, -1)
+	results := make([]*Jv, len(matches))
+	for i, match := range matches {
+		results[i] = namedCaptures(re, str, match)
+	}
+	return results, nil
+}
+
+// backreferenceRegexp matches the jq-style references to named groups in the
+// replacement strings given to Sub and Gsub, which are written \(name) or, as
+// in jq's string interpolation, \(.name).
+var backreferenceRegexp = regexp.MustCompile(`\\\(\.?([a-zA-Z_][a-zA-Z0-9_]*)\)`)
+
+// expandTemplate converts a replacement string using jq-style references to
+// named groups into a template for regexp.Regexp.Expand, escaping any other
+// dollar signs so they are kept literally.
+func expandTemplate(replacement string) string {
+	var template strings.Builder
+	last := 0
+	for _, match := range backreferenceRegexp.FindAllStringSubmatchIndex(replacement, -1) {
+		template.WriteString(strings.Replace(replacement[last:match[0]], "$", "$$", -1))
+		template.WriteString("${" + replacement[match[2]:match[3]] + "}")
+		last = match[1]
+	}
+	template.WriteString(strings.Replace(replacement[last:], "$", "$$", -1))
+	return template.String()
+}
+
+// Sub returns a new string-typed jv with the first match of the regular
+// expression pattern in jv replaced by replacement, like jq's `sub(re; s)`.
+// Named groups can be referenced in replacement as \(name).
+//
+// Patterns use the syntax of Go's regexp package; see Test. Compiled
+// patterns are cached.
+//
+// Returns a *KindError if jv is not a string, or an error if pattern is not a
+// valid regular expression.
+//
+// Does not consume the invocant.
+func (jv *Jv) Sub(pattern, replacement string) (*Jv, error) {
+	return jv.substitute("Sub", pattern, replacement, 1)
+}
+
+// Gsub returns a new string-typed jv with every match of the regular
+// expression pattern in jv replaced by replacement, like jq's `gsub(re; s)`.
+// Named groups can be referenced in replacement as \(name).
+//
+// Patterns use the syntax of Go's regexp package; see Test. Compiled
+// patterns are cached.
+//
+// Returns a *KindError if jv is not a string, or an error if pattern is not a
+// valid regular expression.
+//
+// Does not consume the invocant.
+func (jv *Jv) Gsub(pattern, replacement string) (*Jv, error) {
+	return jv.substitute("Gsub", pattern, replacement, -1)
+}
+
+// substitute replaces up to n matches of pattern in jv, or all of them if n
+// is negative.
+func (jv *Jv) substitute(op, pattern, replacement string, n int) (*Jv, error) {
+	str, err := jv.StringValue()
+	if err != nil {
+		return nil, &KindError{Op: op, Kind: jv.Kind()}
+	}
+
+	re, err := compileCached(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	template := expandTemplate(replacement)
+	var result []byte
+	last := 0
+	for _, match := range re.FindAllStringSubmatchIndex(str, n) {
+		result = append(result, str[last:match[0]]...)
+		result = re.ExpandString(result, template, str, match)
+		last = match[1]
+	}
+	result = append(result, str[last:]...)
+	return JvFromString(string(result)), nil
+}
@@ -0,0 +1,250 @@
+// Copyright (c) 2018 Jimmy Zelinskie
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package jq_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jzelinskie/faq/jq"
+)
+
+func TestJvTest(t *testing.T) {
+	table := []struct {
+		testName string
+		input    string
+		pattern  string
+		match    bool
+	}{
+		{"Match", "foo-123", `\d+`, true},
+		{"NoMatch", "foo", `\d+`, false},
+		{"Anchored", "foo-123", `^\d+$`, false},
+		{"CaseInsensitive", "FOO", `(?i)foo`, true},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			jv := jq.JvFromString(tt.input)
+			defer jv.Free()
+
+			// Run twice so that the second run goes through the regexp cache.
+			for i := 0; i < 2; i++ {
+				match, err := jv.Test(tt.pattern)
+				if err != nil {
+					t.Fatalf("Test() failed: %s", err)
+				}
+				if match != tt.match {
+					t.Errorf("Test() got: %t, want: %t", match, tt.match)
+				}
+			}
+		})
+	}
+
+	jv := jq.JvFromString("foo")
+	defer jv.Free()
+	if _, err := jv.Test(`(`); err == nil {
+		t.Errorf("Test() with an invalid pattern did not return an error")
+	}
+
+	num := jq.JvFromFloat(1)
+	defer num.Free()
+	if _, err := num.Test(`1`); err == nil {
+		t.Errorf("Test() on a number did not return an error")
+	}
+}
+
+func TestJvTestRegexpCacheEviction(t *testing.T) {
+	jv := jq.JvFromString("foo-42")
+	defer jv.Free()
+
+	// Use more distinct patterns than are cached, then go back to the first
+	// ones, which have been evicted and must be compiled again.
+	for round := 0; round < 2; round++ {
+		for i := 0; i < 300; i++ {
+			match, err := jv.Test(fmt.Sprintf(`^foo-%d$`, i))
+			if err != nil {
+				t.Fatalf("Test() failed: %s", err)
+			}
+			if match != (i == 42) {
+				t.Errorf("Test() with pattern %d got: %t, want: %t", i, match, i == 42)
+			}
+		}
+	}
+}
+
+func TestJvCapture(t *testing.T) {
+	table := []struct {
+		testName string
+		input    string
+		pattern  string
+		output   string
+	}{
+		{"Named", "xyzzy-14", `(?<a>[a-z]+)-(?<n>[0-9]+)`, `{"a":"xyzzy","n":"14"}`},
+		{"GoSyntax", "xyzzy-14", `(?P<a>[a-z]+)-(?P<n>[0-9]+)`, `{"a":"xyzzy","n":"14"}`},
+		{"FirstMatchOnly", "a-1 b-2", `(?<a>[a-z])-(?<n>[0-9])`, `{"a":"a","n":"1"}`},
+		{"UnnamedIgnored", "a-1", `([a-z])-(?<n>[0-9])`, `{"n":"1"}`},
+		{"Unmatched", "a", `(?<a>[a-z])(?<n>[0-9])?`, `{"a":"a","n":null}`},
+		{"NoMatch", "abc", `(?<n>[0-9])`, `null`},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			jv := jq.JvFromString(tt.input)
+			defer jv.Free()
+
+			captures, err := jv.Capture(tt.pattern)
+			if err != nil {
+				t.Fatalf("Capture() failed: %s", err)
+			}
+			if dump := captures.Dump(jq.JvPrintNone); dump != tt.output {
+				t.Errorf("Capture() got: %s, want: %s", dump, tt.output)
+			}
+		})
+	}
+
+	num := jq.JvFromFloat(1)
+	defer num.Free()
+	if _, err := num.Capture(`(?<n>1)`); err == nil {
+		t.Errorf("Capture() on a number did not return an error")
+	}
+}
+
+func TestJvScan(t *testing.T) {
+	table := []struct {
+		testName string
+		input    string
+		pattern  string
+		output   []string
+	}{
+		{"Digits", "a1b22c333", `\d+`, []string{`"1"`, `"22"`, `"333"`}},
+		{"NonOverlapping", "aaaa", `aa`, []string{`"aa"`, `"aa"`}},
+		{"NoMatch", "abc", `\d`, []string{}},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			jv := jq.JvFromString(tt.input)
+			defer jv.Free()
+
+			results, err := jv.Scan(tt.pattern)
+			if err != nil {
+				t.Fatalf("Scan() failed: %s", err)
+			}
+			if len(results) != len(tt.output) {
+				t.Fatalf("Scan() got %d results, want: %d", len(results), len(tt.output))
+			}
+			for i, result := range results {
+				if dump := result.Dump(jq.JvPrintNone); dump != tt.output[i] {
+					t.Errorf("Scan()[%d] got: %s, want: %s", i, dump, tt.output[i])
+				}
+			}
+		})
+	}
+
+	num := jq.JvFromFloat(1)
+	defer num.Free()
+	if _, err := num.Scan(`1`); err == nil {
+		t.Errorf("Scan() on a number did not return an error")
+	}
+}
+
+func TestJvScanCaptures(t *testing.T) {
+	jv := jq.JvFromString("a=1, b=2, c")
+	defer jv.Free()
+
+	results, err := jv.ScanCaptures(`(?<key>[a-z])(=(?<value>\d))?`)
+	if err != nil {
+		t.Fatalf("ScanCaptures() failed: %s", err)
+	}
+
+	output := []string{
+		`{"key":"a","value":"1"}`,
+		`{"key":"b","value":"2"}`,
+		`{"key":"c","value":null}`,
+	}
+	if len(results) != len(output) {
+		t.Fatalf("ScanCaptures() got %d results, want: %d", len(results), len(output))
+	}
+	for i, result := range results {
+		if dump := result.Dump(jq.JvPrintNone); dump != output[i] {
+			t.Errorf("ScanCaptures()[%d] got: %s, want: %s", i, dump, output[i])
+		}
+	}
+
+	if _, err := jv.ScanCaptures(`(`); err == nil {
+		t.Errorf("ScanCaptures() with an invalid pattern did not return an error")
+	}
+}
+
+func TestJvSub(t *testing.T) {
+	table := []struct {
+		testName    string
+		input       string
+		pattern     string
+		replacement string
+		sub         string
+		gsub        string
+	}{
+		{"Simple", "foo bar foo", `foo`, "baz", "baz bar foo", "baz bar baz"},
+		{"NoMatch", "foo", `bar`, "baz", "foo", "foo"},
+		{"NamedGroup", "a-1 b-2", `(?<k>[a-z])-(?<v>\d)`, `\(v)=\(k)`, "1=a b-2", "1=a 2=b"},
+		{"NamedGroupWithDot", "a-1", `(?<k>[a-z])-(?<v>\d)`, `\(.v)=\(.k)`, "1=a", "1=a"},
+		{"LiteralDollar", "price", `price`, "$1 $x", "$1 $x", "$1 $x"},
+		{"EmptyMatches", "abc", `x*`, "-", "-abc", "-a-b-c-"},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			jv := jq.JvFromString(tt.input)
+			defer jv.Free()
+
+			sub, err := jv.Sub(tt.pattern, tt.replacement)
+			if err != nil {
+				t.Fatalf("Sub() failed: %s", err)
+			}
+			defer sub.Free()
+			if str, _ := sub.String(); str != tt.sub {
+				t.Errorf("Sub() got: %q, want: %q", str, tt.sub)
+			}
+
+			gsub, err := jv.Gsub(tt.pattern, tt.replacement)
+			if err != nil {
+				t.Fatalf("Gsub() failed: %s", err)
+			}
+			defer gsub.Free()
+			if str, _ := gsub.String(); str != tt.gsub {
+				t.Errorf("Gsub() got: %q, want: %q", str, tt.gsub)
+			}
+		})
+	}
+
+	jv := jq.JvFromString("foo")
+	defer jv.Free()
+	if _, err := jv.Gsub(`(`, ""); err == nil {
+		t.Errorf("Gsub() with an invalid pattern did not return an error")
+	}
+
+	num := jq.JvFromFloat(1)
+	defer num.Free()
+	if _, err := num.Sub(`1`, "2"); err == nil {
+		t.Errorf("Sub() on a number did not return an error")
+	}
+}
@@ -0,0 +1,231 @@
+// Copyright (c) 2018 Jimmy Zelinskie
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package jq
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ghodss/yaml"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Format is the format of the documents read by a JvScanner.
+type Format int
+
+// The formats that can be read by a JvScanner.
+const (
+	// FormatJSON is a stream of JSON texts, optionally separated by
+	// whitespace.
+	FormatJSON Format = iota
+
+	// FormatYAML is a stream of YAML documents separated by "---".
+	FormatYAML
+)
+
+// DefaultMaxDocumentSize is the default maximum size of a document read by a
+// JvScanner, which can be changed with WithBufferSize.
+const DefaultMaxDocumentSize = 64 * 1024
+
+// ErrTooLong is returned by JvScanner.Err when a document is larger than the
+// buffer size of the scanner.
+var ErrTooLong = errors.New("jq.JvScanner: document too long")
+
+// JvScanner reads a stream of documents as Jvs, one at a time, in the same
+// way as a bufio.Scanner reads lines.
+//
+//	scanner := jq.NewJvScanner(r, jq.FormatJSON)
+//	for scanner.Scan() {
+//		jv := scanner.Jv()
+//		...
+//	}
+//	if err := scanner.Err(); err != nil {
+//		...
+//	}
+type JvScanner struct {
+	r       io.Reader
+	format  Format
+	maxSize int
+
+	next func() ([]byte, error)
+	jv   *Jv
+	err  error
+}
+
+// NewJvScanner returns a JvScanner reading documents of the given format
+// from r.
+func NewJvScanner(r io.Reader, format Format) *JvScanner {
+	s := &JvScanner{r: r, format: format, maxSize: DefaultMaxDocumentSize}
+	if format != FormatJSON && format != FormatYAML {
+		s.err = fmt.Errorf("unsupported format %d", format)
+	}
+	return s
+}
+
+// WithBufferSize sets the maximum size in bytes of a document, which is 64KB
+// by default and may be as large as memory allows. Reading a larger document
+// stops the scanner with ErrTooLong. JSON documents are only read up to the
+// maximum size, while YAML documents are read in full and limited by the size
+// of the JSON they are converted to.
+//
+// WithBufferSize panics if it is called after Scan.
+func (s *JvScanner) WithBufferSize(n int) *JvScanner {
+	if s.next != nil {
+		panic("jq: WithBufferSize called after Scan")
+	}
+	s.maxSize = n
+	return s
+}
+
+// Scan advances the scanner to the next document, which is then available
+// through Jv. It returns false once there are no more documents or an error
+// occurred, which is then returned by Err.
+//
+// The Jv of the previous document is freed.
+func (s *JvScanner) Scan() bool {
+	if s.jv != nil {
+		s.jv.Free()
+		s.jv = nil
+	}
+	if s.err != nil {
+		return false
+	}
+
+	if s.next == nil {
+		switch s.format {
+		case FormatJSON:
+			s.next = jsonDocuments(s.r, s.maxSize)
+		case FormatYAML:
+			s.next = yamlDocuments(s.r)
+		}
+	}
+
+	jsonBytes, err := s.next()
+	if err == io.EOF {
+		return false
+	} else if err != nil {
+		s.err = err
+		return false
+	}
+	if len(jsonBytes) > s.maxSize {
+		s.err = ErrTooLong
+		return false
+	}
+
+	s.jv, s.err = JvFromJSONBytes(jsonBytes)
+	return s.err == nil
+}
+
+// Jv returns a copy of the document read by the most recent call to Scan.
+func (s *JvScanner) Jv() *Jv {
+	if s.jv == nil {
+		return nil
+	}
+	return s.jv.Copy()
+}
+
+// Err returns the error that stopped the scanner, if any. Reaching the end of
+// the input isn't an error.
+func (s *JvScanner) Err() error {
+	return s.err
+}
+
+// jsonDocuments returns a function returning each JSON text read from r,
+// failing with ErrTooLong once more than maxSize bytes of a text are read.
+func jsonDocuments(r io.Reader, maxSize int) func() ([]byte, error) {
+	limited := &documentLimitReader{r: r, maxSize: int64(maxSize)}
+	decoder := json.NewDecoder(limited)
+	limited.consumed = decoder.InputOffset
+	return func() ([]byte, error) {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return nil, err
+		}
+		return raw, nil
+	}
+}
+
+// documentLimitReader reads from r as long as no more than maxSize bytes
+// that haven't been consumed by the decoder are buffered, which means that
+// the document being decoded is too long.
+type documentLimitReader struct {
+	r        io.Reader
+	read     int64
+	maxSize  int64
+	consumed func() int64
+}
+
+func (l *documentLimitReader) Read(p []byte) (int, error) {
+	available := l.maxSize + 1 - (l.read - l.consumed())
+	if available <= 0 {
+		return 0, ErrTooLong
+	}
+	if int64(len(p)) > available {
+		p = p[:available]
+	}
+
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	return n, err
+}
+
+// yamlDocuments returns a function returning each YAML document read from r
+// converted to JSON. Empty documents are skipped.
+func yamlDocuments(r io.Reader) func() ([]byte, error) {
+	decoder := yamlv3.NewDecoder(r)
+	return func() ([]byte, error) {
+		for {
+			var node yamlv3.Node
+			if err := decoder.Decode(&node); err != nil {
+				return nil, err
+			}
+			if len(node.Content) == 0 || isEmptyYAMLDocument(node.Content[0]) {
+				continue
+			}
+			quoteYAMLStrings(&node)
+
+			yamlBytes, err := yamlv3.Marshal(&node)
+			if err != nil {
+				return nil, err
+			}
+			return yaml.YAMLToJSON(yamlBytes)
+		}
+	}
+}
+
+// isEmptyYAMLDocument reports whether node is the content of a document with
+// nothing in it, as opposed to one holding an explicit null.
+func isEmptyYAMLDocument(node *yamlv3.Node) bool {
+	return node.Kind == yamlv3.ScalarNode && node.ShortTag() == "!!null" && node.Value == ""
+}
+
+// quoteYAMLStrings quotes every string in node so that strings such as "y"
+// aren't read back as booleans when converted to JSON.
+func quoteYAMLStrings(node *yamlv3.Node) {
+	if node.Kind == yamlv3.ScalarNode && node.ShortTag() == "!!str" {
+		node.Style = yamlv3.DoubleQuotedStyle
+	}
+	for _, child := range node.Content {
+		quoteYAMLStrings(child)
+	}
+}
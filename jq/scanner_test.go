@@ -0,0 +1,108 @@
+// Copyright (c) 2018 Jimmy Zelinskie
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package jq_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jzelinskie/faq/jq"
+)
+
+func TestJvScanner(t *testing.T) {
+	table := []struct {
+		testName string
+		input    string
+		format   jq.Format
+		expected []string
+		err      bool
+	}{
+		{"ConcatenatedJSON", `{"a":1}{"b":2}`, jq.FormatJSON, []string{`{"a":1}`, `{"b":2}`}, false},
+		{"WhitespaceSeparatedJSON", "1\n\"two\" [3]\n", jq.FormatJSON, []string{`1`, `"two"`, `[3]`}, false},
+		{"EmptyJSON", "", jq.FormatJSON, nil, false},
+		{"InvalidJSON", `{"a":1} {"b":`, jq.FormatJSON, []string{`{"a":1}`}, true},
+		{"MultiDocumentYAML", "a: 1\n---\n- x\n- y\n---\nname: web\n", jq.FormatYAML, []string{`{"a":1}`, `["x","y"]`, `{"name":"web"}`}, false},
+		{"EmptyYAMLDocuments", "---\n---\na: 1\n---\n", jq.FormatYAML, []string{`{"a":1}`}, false},
+		{"InvalidYAML", "a: 1\n---\na: [\n", jq.FormatYAML, []string{`{"a":1}`}, true},
+		{"UnknownFormat", `{}`, jq.Format(-1), nil, true},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			scanner := jq.NewJvScanner(strings.NewReader(tt.input), tt.format)
+
+			var actual []string
+			for scanner.Scan() {
+				actual = append(actual, scanner.Jv().Dump(jq.JvPrintNone))
+			}
+			if (scanner.Err() != nil) != tt.err {
+				t.Fatalf("unexpected error: %v", scanner.Err())
+			}
+			if strings.Join(actual, " ") != strings.Join(tt.expected, " ") {
+				t.Errorf("expected %v, got %v", tt.expected, actual)
+			}
+			if scanner.Scan() {
+				t.Error("expected Scan to keep returning false")
+			}
+		})
+	}
+}
+
+func TestJvScannerWithBufferSize(t *testing.T) {
+	// A single 10MB JSON value.
+	large := `{"data":"` + strings.Repeat("x", 10*1024*1024) + `"}`
+
+	table := []struct {
+		testName string
+		input    string
+		format   jq.Format
+		size     int
+		count    int
+		err      error
+	}{
+		{"DefaultTooLong", large, jq.FormatJSON, 0, 0, jq.ErrTooLong},
+		{"Large", large + " " + large, jq.FormatJSON, 11 * 1024 * 1024, 2, nil},
+		{"ExactSize", `[1,2] [3,4]`, jq.FormatJSON, 5, 2, nil},
+		{"SecondTooLong", `[1,2] [3,4,5]`, jq.FormatJSON, 5, 1, jq.ErrTooLong},
+		{"YAMLTooLong", "a: 1\n---\nab: 1\n", jq.FormatYAML, 7, 1, jq.ErrTooLong},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			scanner := jq.NewJvScanner(strings.NewReader(tt.input), tt.format)
+			if tt.size > 0 {
+				scanner = scanner.WithBufferSize(tt.size)
+			}
+
+			count := 0
+			for scanner.Scan() {
+				count++
+			}
+			if !errors.Is(scanner.Err(), tt.err) {
+				t.Fatalf("expected error %v, got %v", tt.err, scanner.Err())
+			}
+			if count != tt.count {
+				t.Errorf("expected %d values, got %d", tt.count, count)
+			}
+		})
+	}
+}
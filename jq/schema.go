@@ -0,0 +1,126 @@
+// Copyright (c) 2018 Jimmy Zelinskie
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package jq
+
+import (
+	"errors"
+	"math"
+)
+
+// ToSchema returns a new object-typed jv holding a JSON Schema (draft-07) that
+// jv validates against, inferred from jv as its only example.
+//
+// Every key of an object is required. The items of an array are described by
+// the schema of its elements if they all have the same one, or by an anyOf of
+// the distinct schemas of its elements otherwise. Whole numbers are given the
+// integer type.
+//
+// Returns an error if jv is invalid.
+//
+// Does not consume the invocant.
+func (jv *Jv) ToSchema() (*Jv, error) {
+	if !jv.IsValid() {
+		return nil, errors.New("ToSchema: jv is invalid")
+	}
+
+	schema := JvObject().
+		ObjectSet(JvFromString("$schema"), JvFromString("http://json-schema.org/draft-07/schema#")).
+		ObjectSet(JvFromString("title"), JvFromString("Schema inferred from an example "+jv.Kind().String()))
+	return inferSchema(jv, schema), nil
+}
+
+// inferSchema adds the keywords describing jv to the object-typed schema.
+//
+// Consumes schema. Does not consume jv.
+func inferSchema(jv *Jv, schema *Jv) *Jv {
+	var schemaType string
+	switch jv.Kind() {
+	case JvKindNull:
+		schemaType = "null"
+	case JvKindTrue, JvKindFalse:
+		schemaType = "boolean"
+	case JvKindNumber:
+		schemaType = "number"
+		if f, _ := jv.ToFloat64(); f == math.Trunc(f) && !math.IsInf(f, 0) {
+			schemaType = "integer"
+		}
+	case JvKindString:
+		schemaType = "string"
+	case JvKindArray:
+		schemaType = "array"
+	case JvKindObject:
+		schemaType = "object"
+	}
+	schema = schema.ObjectSet(JvFromString("type"), JvFromString(schemaType))
+
+	switch jv.Kind() {
+	case JvKindArray:
+		if items := inferItemsSchema(jv); items != nil {
+			schema = schema.ObjectSet(JvFromString("items"), items)
+		}
+	case JvKindObject:
+		properties := JvObject()
+		var required []string
+		jv.ObjectForEach(func(key string, value *Jv) error {
+			properties = properties.ObjectSet(JvFromString(key), inferSchema(value, JvObject()))
+			required = append(required, key)
+			return nil
+		})
+		schema = schema.ObjectSet(JvFromString("properties"), properties)
+		if len(required) > 0 {
+			schema = schema.ObjectSet(JvFromString("required"), JvFromStringSlice(required))
+		}
+	}
+
+	return schema
+}
+
+// inferItemsSchema returns the schema of the items of an array-typed jv, or
+// nil if it is empty.
+//
+// Does not consume jv.
+func inferItemsSchema(jv *Jv) *Jv {
+	var schemas []*Jv
+	seen := make(map[string]bool)
+	length := jv.Copy().ArrayLength()
+	for i := 0; i < length; i++ {
+		elem := jv.Copy().ArrayGet(i)
+		schema := inferSchema(elem, JvObject())
+		elem.Free()
+
+		key := schema.Copy().Dump(JvPrintSorted)
+		if seen[key] {
+			schema.Free()
+			continue
+		}
+		seen[key] = true
+		schemas = append(schemas, schema)
+	}
+
+	switch len(schemas) {
+	case 0:
+		return nil
+	case 1:
+		return schemas[0]
+	default:
+		return JvObject().ObjectSet(JvFromString("anyOf"), jvFromSlice(schemas))
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2018 Jimmy Zelinskie
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package jq_test
+
+import (
+	"testing"
+
+	"github.com/jzelinskie/faq/jq"
+)
+
+func TestJvToSchema(t *testing.T) {
+	const header = `"$schema":"http://json-schema.org/draft-07/schema#",`
+
+	table := []struct {
+		testName string
+		input    string
+		output   string
+	}{
+		{"Integer", `3`, `{` + header + `"title":"Schema inferred from an example number","type":"integer"}`},
+		{"Number", `1.5`, `{` + header + `"title":"Schema inferred from an example number","type":"number"}`},
+		{"Object", `{"name":"faq","ok":true,"none":null}`, `{` + header + `"title":"Schema inferred from an example object","type":"object","properties":{"name":{"type":"string"},"ok":{"type":"boolean"},"none":{"type":"null"}},"required":["name","ok","none"]}`},
+		{"EmptyObject", `{}`, `{` + header + `"title":"Schema inferred from an example object","type":"object","properties":{}}`},
+		{"EmptyArray", `[]`, `{` + header + `"title":"Schema inferred from an example array","type":"array"}`},
+		{"Array", `[1,2,3]`, `{` + header + `"title":"Schema inferred from an example array","type":"array","items":{"type":"integer"}}`},
+		{"MixedArray", `[1,"a",2,{"b":[]}]`, `{` + header + `"title":"Schema inferred from an example array","type":"array","items":{"anyOf":[{"type":"integer"},{"type":"string"},{"type":"object","properties":{"b":{"type":"array"}},"required":["b"]}]}}`},
+		{"NestedMixedArray", `{"a":[[true],[1.5,null]]}`, `{` + header + `"title":"Schema inferred from an example object","type":"object","properties":{"a":{"type":"array","items":{"anyOf":[{"type":"array","items":{"type":"boolean"}},{"type":"array","items":{"anyOf":[{"type":"number"},{"type":"null"}]}}]}}},"required":["a"]}`},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			input := mustParse(t, tt.input)
+			defer input.Free()
+
+			schema, err := input.ToSchema()
+			if err != nil {
+				t.Fatalf("ToSchema() failed: %s", err)
+			}
+			if got := schema.Dump(jq.JvPrintNone); got != tt.output {
+				t.Errorf("ToSchema() got: %s, want: %s", got, tt.output)
+			}
+		})
+	}
+}
+
+func TestJvToSchemaInvalid(t *testing.T) {
+	if _, err := jq.JvInvalid().ToSchema(); err == nil {
+		t.Error("ToSchema() of an invalid jv unexpectedly succeeded")
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2018 Jimmy Zelinskie
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package jq
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// undefinedVariableRegexp matches the error libjq reports for each reference
+// to a variable that isn't bound.
+var undefinedVariableRegexp = regexp.MustCompile(`^jq: error: \$([a-zA-Z_][a-zA-Z0-9_]*) is not defined at `)
+
+// compileErrorCountRegexp matches the summary libjq reports after the errors
+// of a program that failed to compile.
+var compileErrorCountRegexp = regexp.MustCompile(`^jq: \d+ compile errors?$`)
+
+// FreeVariables returns the names, without the "$", of the variables that
+// program references but doesn't bind itself, in the order they are first
+// referenced. These are the variables that must be provided as arguments for
+// program to compile. Builtin variables, such as $ENV, are not included.
+//
+// libjq doesn't expose the symbols of a compiled program, so program is
+// compiled without any arguments and the variables are taken from the errors
+// reported for those that aren't defined. Any other compile error is
+// returned.
+func FreeVariables(program string) ([]string, error) {
+	jq, err := New()
+	if err != nil {
+		return nil, err
+	}
+	defer jq.Close()
+
+	var names []string
+	seen := make(map[string]bool)
+	var compileErrs []string
+	for _, err := range jq.Compile(program, JvArray()) {
+		if err == nil {
+			continue
+		}
+
+		msg := err.Error()
+		if match := undefinedVariableRegexp.FindStringSubmatch(msg); match != nil {
+			if !seen[match[1]] {
+				seen[match[1]] = true
+				names = append(names, match[1])
+			}
+		} else if !compileErrorCountRegexp.MatchString(msg) {
+			compileErrs = append(compileErrs, msg)
+		}
+	}
+
+	if len(compileErrs) > 0 {
+		return nil, errors.New(strings.Join(compileErrs, "\n"))
+	}
+	return names, nil
+}
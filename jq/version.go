@@ -0,0 +1,81 @@
+// Copyright (c) 2018 Jimmy Zelinskie
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package jq
+
+/*
+#include <jq.h>
+
+// faq_jq_version returns the version of libjq if its headers define it, which
+// those of jq 1.6 and earlier don't.
+static const char *faq_jq_version(void) {
+#ifdef JQ_VERSION
+	return JQ_VERSION;
+#else
+	return NULL;
+#endif
+}
+*/
+import "C"
+import "sync"
+
+var version struct {
+	sync.Once
+	v string
+}
+
+// Version returns the version of libjq that faq was built against, such as
+// "1.7.1". The headers of jq 1.6 and earlier don't include the version, in
+// which case it is worked out from the builtins that libjq supports and is
+// either "1.7 or later", "1.6" or "1.5 or earlier".
+func Version() string {
+	version.Do(func() {
+		if v := C.faq_jq_version(); v != nil {
+			version.v = C.GoString(v)
+			return
+		}
+
+		switch {
+		case compiles("pick(.a)"):
+			version.v = "1.7 or later"
+		case compiles("$ENV"):
+			version.v = "1.6"
+		default:
+			version.v = "1.5 or earlier"
+		}
+	})
+	return version.v
+}
+
+// compiles reports whether program compiles with libjq.
+func compiles(program string) bool {
+	jq, err := New()
+	if err != nil {
+		return false
+	}
+	defer jq.Close()
+
+	for _, err := range jq.Compile(program, JvArray()) {
+		if err != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2018 Jimmy Zelinskie
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package jq
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"time"
+)
+
+// keyUsageNames are the names of the bits of x509.KeyUsage, in order.
+var keyUsageNames = []string{
+	"digital_signature",
+	"content_commitment",
+	"key_encipherment",
+	"data_encipherment",
+	"key_agreement",
+	"cert_sign",
+	"crl_sign",
+	"encipher_only",
+	"decipher_only",
+}
+
+// JvFromTLSCertificate returns a new object-typed jv describing cert, with the
+// fields:
+//
+//	subject, issuer:        distinguished names, such as "CN=example.com,O=faq"
+//	dns_names:              array of strings
+//	ip_addresses:           array of strings
+//	not_before, not_after:  RFC 3339 timestamps
+//	serial_number:          decimal string, as it may not fit in a number
+//	key_usage:              array of names, such as "digital_signature"
+//
+// Returns an error if cert is nil.
+func JvFromTLSCertificate(cert *x509.Certificate) (*Jv, error) {
+	if cert == nil {
+		return nil, errors.New("JvFromTLSCertificate: certificate is nil")
+	}
+
+	var ipAddresses []string
+	for _, ip := range cert.IPAddresses {
+		ipAddresses = append(ipAddresses, ip.String())
+	}
+
+	var keyUsage []string
+	for i, name := range keyUsageNames {
+		if cert.KeyUsage&(1<<uint(i)) != 0 {
+			keyUsage = append(keyUsage, name)
+		}
+	}
+
+	return JvObject().
+		ObjectSet(JvFromString("subject"), JvFromString(cert.Subject.String())).
+		ObjectSet(JvFromString("issuer"), JvFromString(cert.Issuer.String())).
+		ObjectSet(JvFromString("dns_names"), JvFromStringSlice(cert.DNSNames)).
+		ObjectSet(JvFromString("ip_addresses"), JvFromStringSlice(ipAddresses)).
+		ObjectSet(JvFromString("not_before"), JvFromString(cert.NotBefore.UTC().Format(time.RFC3339))).
+		ObjectSet(JvFromString("not_after"), JvFromString(cert.NotAfter.UTC().Format(time.RFC3339))).
+		ObjectSet(JvFromString("serial_number"), JvFromString(cert.SerialNumber.String())).
+		ObjectSet(JvFromString("key_usage"), JvFromStringSlice(keyUsage)), nil
+}
+
+// ParsePEMCertificate parses the first PEM-encoded certificate in pemBytes and
+// converts it with JvFromTLSCertificate.
+//
+// Returns an error if pemBytes has no CERTIFICATE block or the certificate
+// can't be parsed.
+func ParsePEMCertificate(pemBytes []byte) (*Jv, error) {
+	for {
+		var block *pem.Block
+		block, pemBytes = pem.Decode(pemBytes)
+		if block == nil {
+			return nil, errors.New("ParsePEMCertificate: no PEM-encoded certificate found")
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return JvFromTLSCertificate(cert)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2018 Jimmy Zelinskie
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package jq_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/jzelinskie/faq/jq"
+)
+
+// newTestCertificate returns a self-signed certificate, DER-encoded.
+func newTestCertificate(t *testing.T) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+
+	serial, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "example.com", Organization: []string{"faq"}},
+		DNSNames:     []string{"example.com", "www.example.com"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("::1")},
+		NotBefore:    time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:     time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %s", err)
+	}
+	return der
+}
+
+func TestParsePEMCertificate(t *testing.T) {
+	der := newTestCertificate(t)
+	pemBytes := append(
+		pem.EncodeToMemory(&pem.Block{Type: "EC PARAMETERS", Bytes: []byte{0}}),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+
+	jv, err := jq.ParsePEMCertificate(pemBytes)
+	if err != nil {
+		t.Fatalf("ParsePEMCertificate() failed: %s", err)
+	}
+
+	want := `{"subject":"CN=example.com,O=faq","issuer":"CN=example.com,O=faq",` +
+		`"dns_names":["example.com","www.example.com"],"ip_addresses":["127.0.0.1","::1"],` +
+		`"not_before":"2024-01-01T00:00:00Z","not_after":"2025-01-01T00:00:00Z",` +
+		`"serial_number":"123456789012345678901234567890","key_usage":["digital_signature","cert_sign"]}`
+	if got := jv.Dump(jq.JvPrintNone); got != want {
+		t.Errorf("ParsePEMCertificate() got: %s, want: %s", got, want)
+	}
+}
+
+func TestParsePEMCertificateErrors(t *testing.T) {
+	table := []struct {
+		testName string
+		input    []byte
+	}{
+		{"Empty", nil},
+		{"NotPEM", []byte("not a certificate")},
+		{"NoCertificate", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte{0}})},
+		{"InvalidCertificate", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte{0}})},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.testName, func(t *testing.T) {
+			if jv, err := jq.ParsePEMCertificate(tt.input); err == nil {
+				t.Errorf("ParsePEMCertificate() got: %s, want an error", jv.Dump(jq.JvPrintNone))
+			}
+		})
+	}
+}
+
+func TestJvFromTLSCertificateNil(t *testing.T) {
+	if _, err := jq.JvFromTLSCertificate(nil); err == nil {
+		t.Error("JvFromTLSCertificate(nil) unexpectedly succeeded")
+	}
+}
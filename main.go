@@ -1,23 +1,36 @@
 package main
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/Azure/draft/pkg/linguist"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"golang.org/x/crypto/ssh/terminal"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/simplifiedchinese"
 
 	"github.com/jzelinskie/faq/formats"
 	"github.com/jzelinskie/faq/jq"
 )
 
+// version is the version of faq, which is set when building releases with
+// -ldflags "-X main.version=...".
+var version = "dev"
+
 func main() {
 	var rootCmd = &cobra.Command{
 		Short: "format agnostic querier",
@@ -29,71 +42,366 @@ faq is pronounced "fah queue".
 Supported formats:
 - BSON
 - Bencode
+- dotenv
 - JSON
+- protojson (with --proto-descriptor)
 - TOML
 - XML
 - YAML
 `,
 
-		Use: "faq [flags] [filter string] [files...]",
+		Use:                   "faq [flags] [filter string] [files...]",
 		DisableFlagsInUseLine: true,
+		Args:                  cobra.ArbitraryArgs,
+
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := configureLogging(cmd); err != nil {
+				return err
+			}
 
-		PreRunE: func(cmd *cobra.Command, args []string) error {
-			if debug, _ := cmd.Flags().GetBool("debug"); debug {
-				logrus.SetLevel(logrus.DebugLevel)
+			// Errors are printed by main once Execute returns when they
+			// aren't plain text.
+			switch errorOutput, _ := cmd.Flags().GetString("error-output"); errorOutput {
+			case "text":
+			case "json", "yaml":
+				cmd.Root().SilenceErrors, cmd.Root().SilenceUsage = true, true
+			default:
+				return fmt.Errorf("unsupported --error-output %s", errorOutput)
 			}
 			return nil
 		},
 
-		RunE: runCmdFunc,
+		RunE:    runCmdFunc,
+		Version: version,
 	}
 
-	rootCmd.Flags().Bool("debug", false, "enable debug logging")
-	rootCmd.Flags().StringP("input-format", "f", "auto", "input format")
-	rootCmd.Flags().StringP("output-format", "o", "auto", "output format")
-	rootCmd.Flags().BoolP("raw-output", "r", false, "output raw strings, not JSON texts")
-	rootCmd.Flags().BoolP("color-output", "c", true, "colorize the output")
-	rootCmd.Flags().BoolP("monochrome-output", "m", false, "monochrome (don't colorize the output)")
-	rootCmd.Flags().BoolP("pretty-output", "p", true, "pretty-printed output")
+	cobra.AddTemplateFunc("libjqVersion", jq.Version)
+	rootCmd.SetVersionTemplate("faq {{.Version}}\nlibjq {{libjqVersion}}\n")
+
+	rootCmd.PersistentFlags().Bool("debug", false, "enable debug logging")
+	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "log what faq is doing to stderr, the same as --log-level info")
+	rootCmd.PersistentFlags().String("log-level", "warn", "level of the messages logged to stderr (debug, info, warn, error)")
+	rootCmd.PersistentFlags().String("log-format", "text", "format of the messages logged to stderr (text, json)")
+	rootCmd.PersistentFlags().String("error-output", "text", "format of errors printed to stderr (text, json, yaml)")
+	rootCmd.PersistentFlags().StringP("input-format", "f", "auto", "input format")
+	rootCmd.PersistentFlags().StringP("output-format", "o", "auto", "output format")
+	rootCmd.PersistentFlags().String("input-encoding", "utf-8", "character encoding of the input (utf-8, latin1, windows-1252, gbk)")
+	rootCmd.PersistentFlags().String("input-format-options", "", "format-specific decoding options as KEY=VALUE,KEY=VALUE")
+	rootCmd.PersistentFlags().StringArray("input-null-value", nil, "treat input strings equal to this value as null (may be repeated)")
+	rootCmd.PersistentFlags().String("proto-descriptor", "", "protobuf FileDescriptorSet used to decode protojson input")
+	rootCmd.PersistentFlags().String("proto-message", "", "fully-qualified protobuf message name of protojson input (defaults to the first message of the descriptor)")
+	rootCmd.PersistentFlags().String("input-default", "", "JSON value to use in place of input files that are missing or empty")
+	rootCmd.PersistentFlags().Bool("ignore-case", false, "lowercase every key of the input, so that keys can be accessed in lowercase whatever their case")
+	rootCmd.PersistentFlags().String("input-transform", "", "shell command to pipe each input file through before decoding it")
+	rootCmd.PersistentFlags().String("max-input-size", "0", "maximum size of each input file, e.g. 10MB (0 is unlimited)")
+	rootCmd.PersistentFlags().BoolP("raw-output", "r", false, "output raw strings, not JSON texts")
+	rootCmd.PersistentFlags().String("field-separator", "", "with --raw-output, join array results of scalars with this separator")
+	rootCmd.PersistentFlags().Bool("no-yaml-separator", false, "don't print \"---\" between YAML documents")
+	rootCmd.PersistentFlags().Bool("omit-empty", false, "don't print results that are null, empty arrays or empty objects")
+	rootCmd.PersistentFlags().BoolP("color-output", "c", true, "colorize the output")
+	rootCmd.PersistentFlags().BoolP("monochrome-output", "m", false, "monochrome (don't colorize the output)")
+	rootCmd.PersistentFlags().BoolP("pretty-output", "p", true, "pretty-printed output")
+	rootCmd.Flags().Bool("repl", false, "interactively run jq programs against a single file")
+	rootCmd.Flags().Bool("labeled-output", false, "prefix each line of output with its file name and \": \"")
+	rootCmd.Flags().Bool("tab-separated", false, "prefix each line of output with its file name and a tab")
+	rootCmd.Flags().String("reduce", "", "fold all files into one value with this jq program, binding each file to $x")
+	rootCmd.Flags().String("reduce-init", "null", "jq expression for the initial value of --reduce")
+	rootCmd.Flags().String("reduce-init-file", "", "file containing the initial value of --reduce")
+	rootCmd.Flags().Bool("progress", false, "report how many files have been processed on stderr")
+	rootCmd.Flags().String("output-template", "", "print each result rendered with this Go template instead of encoding it")
+	rootCmd.Flags().StringSlice("omit-keys", nil, "remove the object keys with these comma-separated names from the output, at any depth")
+	rootCmd.Flags().Bool("preserve-comments", false, "keep the comments of YAML input in YAML output, for the values the jq program doesn't change")
+	rootCmd.Flags().StringArray("arg", nil, "bind the string VALUE to the jq variable $NAME, given as NAME=VALUE (may be repeated)")
+	rootCmd.Flags().StringArray("argjson", nil, "bind the JSON value to the jq variable $NAME, given as NAME=JSON (may be repeated)")
+	rootCmd.Flags().Bool("variables", false, "print the variables the jq program references without running it, failing if any aren't given with --arg or --argjson")
+	rootCmd.Flags().String("input-schema", "", "JSON Schema of the input used to warn about paths in the jq program that it doesn't define")
+
+	rootCmd.PersistentFlags().MarkHidden("debug")
+	rootCmd.RegisterFlagCompletionFunc("input-format", completeFormats)
+	rootCmd.RegisterFlagCompletionFunc("output-format", completeFormats)
+
+	rootCmd.AddCommand(newCatCommand())
+	rootCmd.AddCommand(newCompactCommand())
+	rootCmd.AddCommand(newCompletionsCommand())
+	rootCmd.AddCommand(newCountCommand())
+	rootCmd.AddCommand(newEditCommand())
+	rootCmd.AddCommand(newEnvCommand())
+	rootCmd.AddCommand(newGenerateCommand())
+	rootCmd.AddCommand(newGetCommand())
+	rootCmd.AddCommand(newHashCommand())
+	rootCmd.AddCommand(newSchemaCommand())
+	rootCmd.AddCommand(newSetCommand())
+	rootCmd.AddCommand(newTemplateCommand())
+
+	if err := rootCmd.Execute(); err != nil {
+		if rootCmd.SilenceErrors {
+			errorOutput, _ := rootCmd.PersistentFlags().GetString("error-output")
+			printError(err, errorOutput)
+		}
+		os.Exit(1)
+	}
+}
 
-	rootCmd.Flags().MarkHidden("debug")
+// configureLogging sets up logrus according to the logging flags. At the debug
+// level, every error reported by libjq is logged as it happens.
+func configureLogging(cmd *cobra.Command) error {
+	levelName, _ := cmd.Flags().GetString("log-level")
+	level, err := logrus.ParseLevel(levelName)
+	if err != nil {
+		return fmt.Errorf("invalid --log-level: %s", err)
+	}
+	if verbose, _ := cmd.Flags().GetBool("verbose"); verbose && level < logrus.InfoLevel {
+		level = logrus.InfoLevel
+	}
+	if debug, _ := cmd.Flags().GetBool("debug"); debug {
+		level = logrus.DebugLevel
+	}
+	logrus.SetLevel(level)
+
+	switch format, _ := cmd.Flags().GetString("log-format"); format {
+	case "text":
+	case "json":
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+	default:
+		return fmt.Errorf("unsupported --log-format %s", format)
+	}
 
-	rootCmd.Execute()
+	if level >= logrus.DebugLevel {
+		jq.ErrorHandler = func(err error) {
+			logrus.WithError(err).Debug("libjq reported an error")
+		}
+	}
+	return nil
 }
 
-func runCmdFunc(cmd *cobra.Command, args []string) error {
-	inputFormat, _ := cmd.Flags().GetString("input-format")
-	outputFormat, _ := cmd.Flags().GetString("output-format")
-	raw, _ := cmd.Flags().GetBool("raw-output")
+// formatName returns the name that encoding is registered under.
+func formatName(encoding formats.Encoding) string {
+	name := ""
+	for n, e := range formats.ByName {
+		if e == encoding && (name == "" || n < name) {
+			name = n
+		}
+	}
+	return name
+}
+
+// inputOptions holds the flags that control how input files are decoded.
+type inputOptions struct {
+	format        string
+	formatOptions map[string]string
+	encoding      string
+	maxSize       int64
+	nullValues    map[string]bool
+	ignoreCase    bool
+	transform     string
+	defaultValue  string
+}
+
+func newInputOptions(cmd *cobra.Command) (inputOptions, error) {
+	var opts inputOptions
+	opts.format, _ = cmd.Flags().GetString("input-format")
+	opts.encoding, _ = cmd.Flags().GetString("input-encoding")
+
+	formatOptions, _ := cmd.Flags().GetString("input-format-options")
+	var err error
+	if opts.formatOptions, err = parseFormatOptions(formatOptions); err != nil {
+		return opts, fmt.Errorf("invalid --input-format-options: %s", err)
+	}
+
+	// The protobuf flags are shorthand for the options of the protojson format.
+	protoDescriptor, _ := cmd.Flags().GetString("proto-descriptor")
+	protoMessage, _ := cmd.Flags().GetString("proto-message")
+	if protoDescriptor != "" || protoMessage != "" {
+		if strings.ToLower(opts.format) != "protojson" {
+			return opts, errors.New("--proto-descriptor and --proto-message require --input-format protojson")
+		}
+		opts.formatOptions["descriptor"] = os.ExpandEnv(protoDescriptor)
+		if protoMessage != "" {
+			opts.formatOptions["message"] = protoMessage
+		}
+	}
+
+	nullValues, _ := cmd.Flags().GetStringArray("input-null-value")
+	if len(nullValues) > 0 {
+		opts.nullValues = make(map[string]bool, len(nullValues))
+		for _, value := range nullValues {
+			opts.nullValues[value] = true
+		}
+	}
+
+	opts.ignoreCase, _ = cmd.Flags().GetBool("ignore-case")
+	opts.transform, _ = cmd.Flags().GetString("input-transform")
+
+	if opts.defaultValue, _ = cmd.Flags().GetString("input-default"); opts.defaultValue != "" {
+		defaultJv, err := jq.JvFromJSONString(opts.defaultValue)
+		if err != nil {
+			return opts, fmt.Errorf("invalid --input-default: %s", err)
+		}
+		defaultJv.Free()
+	}
+
+	maxSize, _ := cmd.Flags().GetString("max-input-size")
+	if opts.maxSize, err = parseByteSize(maxSize); err != nil {
+		return opts, fmt.Errorf("invalid --max-input-size: %s", err)
+	}
+
+	return opts, nil
+}
+
+// outputOptions holds the flags that control how results are printed.
+type outputOptions struct {
+	format         string
+	raw            bool
+	fieldSeparator string
+	omitEmpty      bool
+	noSeparator    bool
+	pretty         bool
+	color          bool
+}
+
+func newOutputOptions(cmd *cobra.Command) outputOptions {
+	var opts outputOptions
+	opts.format, _ = cmd.Flags().GetString("output-format")
+	opts.raw, _ = cmd.Flags().GetBool("raw-output")
+	opts.fieldSeparator, _ = cmd.Flags().GetString("field-separator")
+	opts.omitEmpty, _ = cmd.Flags().GetBool("omit-empty")
+	opts.noSeparator, _ = cmd.Flags().GetBool("no-yaml-separator")
+	opts.pretty, _ = cmd.Flags().GetBool("pretty-output")
 	color, _ := cmd.Flags().GetBool("color-output")
-	prettyPrint, _ := cmd.Flags().GetBool("pretty-output")
 	monochrome, _ := cmd.Flags().GetBool("monochrome-output")
 	if runtime.GOOS == "windows" {
 		monochrome = true
 	}
 
+	// Only colorize when execution is in an interactive terminal.
+	stdoutIsTTY := terminal.IsTerminal(int(os.Stdout.Fd()))
+	opts.color = color && !monochrome && stdoutIsTTY
+	return opts
+}
+
+// pathArgs returns the files to read from the positional arguments, falling
+// back to stdin when it is not an interactive terminal.
+func pathArgs(args []string) ([]string, bool) {
+	if len(args) > 0 {
+		return args, true
+	}
+	if !terminal.IsTerminal(int(os.Stdin.Fd())) {
+		return []string{"/dev/stdin"}, true
+	}
+	return nil, false
+}
+
+func runCmdFunc(cmd *cobra.Command, args []string) error {
+	inOpts, err := newInputOptions(cmd)
+	if err != nil {
+		return err
+	}
+	outOpts := newOutputOptions(cmd)
+
+	vars, err := newVariables(cmd)
+	if err != nil {
+		return err
+	}
+
+	if repl, _ := cmd.Flags().GetBool("repl"); repl {
+		if len(args) != 1 {
+			return fmt.Errorf("--repl requires exactly one file")
+		}
+		return runREPL(os.ExpandEnv(args[0]), vars, inOpts, outOpts)
+	}
+
+	if cmd.Flags().Changed("reduce") {
+		return runReduce(cmd, args, vars, inOpts, outOpts)
+	}
+
+	if listVariables, _ := cmd.Flags().GetBool("variables"); listVariables {
+		if len(args) == 0 {
+			return errors.New("--variables requires a jq program")
+		}
+		cmd.SilenceUsage = true
+		return printVariables(args[0], vars)
+	}
+
+	labelSeparator := ""
+	labeled, _ := cmd.Flags().GetBool("labeled-output")
+	tabSeparated, _ := cmd.Flags().GetBool("tab-separated")
+	if labeled && tabSeparated {
+		return errors.New("--labeled-output and --tab-separated cannot be used together")
+	} else if labeled {
+		labelSeparator = ": "
+	} else if tabSeparated {
+		labelSeparator = "\t"
+	}
+
 	// Check to see execution is in an interactive terminal and set the args
 	// and flags as such.
-	stdoutIsTTY := terminal.IsTerminal(int(os.Stdout.Fd()))
 	stdinIsTTY := terminal.IsTerminal(int(os.Stdin.Fd()))
 	program := ""
-	pathArgs := []string{}
+	paths := []string{}
 	if !stdinIsTTY && len(args) == 0 {
 		program = "."
-		pathArgs = []string{"/dev/stdin"}
-		monochrome = true
+		paths = []string{"/dev/stdin"}
+		outOpts.color = false
 	} else if !stdinIsTTY && len(args) == 1 {
 		program = args[0]
-		pathArgs = []string{"/dev/stdin"}
-		monochrome = true
+		paths = []string{"/dev/stdin"}
+		outOpts.color = false
 	} else if len(args) >= 2 {
 		program = args[0]
-		pathArgs = args[1:]
+		paths = args[1:]
 	} else {
 		return fmt.Errorf("not enough arguments provided")
 	}
 
-	for _, pathArg := range pathArgs {
+	// Variables are bound first so that the program can be compiled before it
+	// is checked against the schema.
+	program = bindVariables(program, vars)
+	if schemaPath, _ := cmd.Flags().GetString("input-schema"); schemaPath != "" {
+		if err := warnSchemaMismatches(program, os.ExpandEnv(schemaPath)); err != nil {
+			return err
+		}
+	}
+
+	var tmpl *template.Template
+	if text, _ := cmd.Flags().GetString("output-template"); text != "" {
+		if cmd.Flags().Changed("raw-output") || cmd.Flags().Changed("pretty-output") {
+			return errors.New("--output-template cannot be used with --raw-output or --pretty-output")
+		}
+		tmpl, err = newOutputTemplate(text)
+		if err != nil {
+			return err
+		}
+	}
+
+	preserveComments, _ := cmd.Flags().GetBool("preserve-comments")
+	if preserveComments && tmpl != nil {
+		return errors.New("--preserve-comments cannot be used with --output-template")
+	}
+
+	var omittedKeys map[string]bool
+	if keys, _ := cmd.Flags().GetStringSlice("omit-keys"); len(keys) > 0 {
+		omittedKeys = make(map[string]bool, len(keys))
+		for _, key := range keys {
+			omittedKeys[key] = true
+		}
+	}
+
+	var p *progress
+	if showProgress, _ := cmd.Flags().GetBool("progress"); showProgress {
+		p = newProgress(len(paths))
+		defer p.clear()
+	}
+
+	first := true
+	documents, results := 0, 0
+	for _, path := range paths {
+		path = os.ExpandEnv(path)
+		label := path
+		if path == "/dev/stdin" {
+			label = "<stdin>"
+		}
+		p.start(label)
+
 		libjq, err := jq.New()
 		if err != nil {
 			return fmt.Errorf("failed to initialize libjq: %s", err)
@@ -102,98 +410,591 @@ func runCmdFunc(cmd *cobra.Command, args []string) error {
 		// Sucks these won't close until runCmdFunc exits.
 		defer libjq.Close()
 
-		path := os.ExpandEnv(pathArg)
-		fileBytes, err := ioutil.ReadFile(path)
+		fileJv, decoder, fileBytes, err := decodeFileBytes(path, inOpts)
 		if err != nil {
-			return fmt.Errorf("failed to read file at %s: `%s`", path, err)
+			return err
 		}
 
-		// If there was no input, there's no output!
-		if len(fileBytes) == 0 {
+		if fileJv == nil {
+			p.done(label)
 			continue
 		}
 
-		var decoder formats.Encoding
-		var ok bool
-		if inputFormat == "auto" {
-			decoder, ok = detectFormat(fileBytes, path)
-			if !ok {
-				return errors.New("failed to detect format of the input")
-			}
-		} else {
-			decoder, ok = formats.ByName[strings.ToLower(inputFormat)]
-			if !ok {
-				return fmt.Errorf("no supported format found named %s", inputFormat)
-			}
-		}
-
-		jsonifiedFile, err := decoder.MarshalJSONBytes(fileBytes)
+		encoder, err := outOpts.encoder(decoder)
 		if err != nil {
-			return fmt.Errorf("failed to jsonify file at %s: `%s`", path, err)
+			fileJv.Free()
+			return err
 		}
 
-		fileJv, err := jq.JvFromJSONBytes(jsonifiedFile)
-		if err != nil {
-			panic("failed to convert jsonified file into jv")
+		var comments *commentedYAML
+		if preserveComments && fileBytes != nil && isYAML(decoder) && isYAML(encoder) {
+			comments, err = newCommentedYAML(fileBytes, program, fileJv)
+			if err != nil {
+				fileJv.Free()
+				return fmt.Errorf("failed to preserve comments of file at %s: %s", path, err)
+			}
 		}
 
 		errs := libjq.Compile(program, jq.JvArray())
 		for _, err := range errs {
 			if err != nil {
-				return fmt.Errorf("failed to compile jq program for file at %s: %s", path, err)
+				return &fileError{path: path, err: fmt.Errorf("failed to compile jq program for file at %s: %s", path, err)}
 			}
 		}
 
+		start := time.Now()
 		resultJvs, err := libjq.Execute(fileJv)
 		if err != nil {
-			return fmt.Errorf("failed to execute jq program for file at %s: %s", path, err)
+			return &fileError{path: path, err: fmt.Errorf("failed to execute jq program for file at %s: %s", path, err)}
 		}
+		logrus.WithFields(logrus.Fields{
+			"file":     path,
+			"results":  len(resultJvs),
+			"duration": time.Since(start).String(),
+		}).Info("evaluated jq program")
 
-		// Determine the encoding for the file output.
-		var encoder formats.Encoding
-		if outputFormat == "auto" {
-			encoder = decoder
-		} else {
-			encoder, ok = formats.ByName[strings.ToLower(outputFormat)]
-			if !ok {
-				return fmt.Errorf("no supported format found named %s", outputFormat)
-			}
-		}
+		documents++
+		results += len(resultJvs)
 
 		// Print the final output.
-		for _, resultJv := range resultJvs {
-			resultBytes := []byte(resultJv.Dump(jq.JvPrintNone))
-			output, err := encoder.UnmarshalJSONBytes(resultBytes)
-			if err != nil {
-				return fmt.Errorf("failed to encode jq program output as %s: %s", outputFormat, err)
+		p.clear()
+		for i, resultJv := range resultJvs {
+			if omittedKeys != nil {
+				resultJv = omitKeys(resultJv, omittedKeys)
+			}
+			if outOpts.omit(resultJv) {
+				resultJv.Free()
+				continue
 			}
 
-			if prettyPrint {
-				output, err = encoder.PrettyPrint(output)
+			var output []byte
+			if tmpl != nil {
+				output, err = renderOutputTemplate(tmpl, resultJv)
 				if err != nil {
-					return fmt.Errorf("failed to encode jq program output as pretty %s: %s", outputFormat, err)
+					fmt.Fprintf(os.Stderr, "failed to render --output-template for file at %s: %s\n", path, err)
+					continue
 				}
-			}
-
-			if raw {
-				output, err = encoder.Raw(output)
+			} else if comments != nil {
+				output, err = outOpts.encodeWithComments(resultJv, comments, i, len(resultJvs))
 				if err != nil {
-					return fmt.Errorf("failed to encode jq program output as raw %s: %s", outputFormat, err)
+					return err
 				}
-			} else if color && !monochrome && stdoutIsTTY {
-				output, err = encoder.Color(output)
+			} else {
+				output, err = outOpts.encode(resultJv, encoder)
 				if err != nil {
-					return fmt.Errorf("failed to encode jq program output as color %s: %s", outputFormat, err)
+					return err
 				}
 			}
 
+			if labelSeparator != "" {
+				output = labelLines(output, label+labelSeparator)
+			} else if separator, ok := outOpts.separator(encoder); ok && !first && tmpl == nil {
+				fmt.Println(separator)
+			}
+			first = false
+
 			fmt.Println(string(output))
 		}
+		if comments != nil {
+			comments.free()
+		}
+		p.done(label)
 	}
 
+	logrus.WithFields(logrus.Fields{"documents": documents, "results": results}).Info("processed all files")
+
 	return nil
 }
 
+// decodeFile reads the file at path and converts it into a Jv, returning the
+// encoding it was decoded from.
+//
+// If the file is empty, the returned Jv is nil, unless there is a default
+// input, which is also used if the file doesn't exist.
+func decodeFile(path string, opts inputOptions) (*jq.Jv, formats.Encoding, error) {
+	fileJv, decoder, _, err := decodeFileBytes(path, opts)
+	return fileJv, decoder, err
+}
+
+// decodeFileBytes is decodeFile, but also returns the contents of the file,
+// which are nil if the default input was used.
+func decodeFileBytes(path string, opts inputOptions) (*jq.Jv, formats.Encoding, []byte, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) && opts.defaultValue != "" {
+		defaultJv, decoder, err := opts.defaultInput()
+		return defaultJv, decoder, nil, err
+	}
+
+	fileBytes, err := readFile(path, opts.encoding, opts.maxSize)
+	if err != nil {
+		return nil, nil, nil, &fileError{path: path, err: fmt.Errorf("failed to read file at %s: `%s`", path, err)}
+	}
+
+	if opts.transform != "" {
+		fileBytes, err = transformInput(fileBytes, opts.transform)
+		if err != nil {
+			return nil, nil, nil, &fileError{path: path, err: fmt.Errorf("failed to transform file at %s: %s", path, err)}
+		}
+	}
+
+	// If there was no input, there's no output!
+	if len(fileBytes) == 0 {
+		if opts.defaultValue != "" {
+			defaultJv, decoder, err := opts.defaultInput()
+			return defaultJv, decoder, nil, err
+		}
+		return nil, nil, nil, nil
+	}
+
+	var decoder formats.Encoding
+	var ok bool
+	if opts.format == "auto" {
+		decoder, ok = detectFormat(fileBytes, path)
+		if !ok {
+			return nil, nil, nil, &fileError{path: path, err: errors.New("failed to detect format of the input")}
+		}
+		logrus.WithFields(logrus.Fields{"file": path, "format": formatName(decoder)}).Info("detected input format")
+	} else {
+		decoder, ok = formats.ByName[strings.ToLower(opts.format)]
+		if !ok {
+			return nil, nil, nil, fmt.Errorf("no supported format found named %s", opts.format)
+		}
+	}
+
+	var jsonifiedFile []byte
+	if len(opts.formatOptions) > 0 {
+		configurable, ok := decoder.(formats.ConfigurableEncoding)
+		if !ok {
+			return nil, nil, nil, fmt.Errorf("input format of %s does not support any options", path)
+		}
+		jsonifiedFile, err = configurable.MarshalJSONBytesWithOptions(fileBytes, opts.formatOptions)
+	} else {
+		jsonifiedFile, err = decoder.MarshalJSONBytes(fileBytes)
+	}
+	if err != nil {
+		jsonifyErr := &fileError{path: path, err: fmt.Errorf("failed to jsonify file at %s: `%s`", path, err)}
+		return nil, nil, nil, jsonifyErr.at(err)
+	}
+
+	fileJv, err := jq.JvFromJSONBytes(jsonifiedFile)
+	if err != nil {
+		parseErr := &fileError{path: path, err: fmt.Errorf("failed to parse file at %s: `%s`", path, err)}
+		return nil, nil, nil, parseErr.at(err)
+	}
+	logrus.WithFields(logrus.Fields{"file": path, "format": formatName(decoder), "bytes": len(fileBytes)}).Info("decoded input")
+
+	if opts.nullValues != nil {
+		fileJv = replaceNullValues(fileJv, opts.nullValues)
+	}
+
+	if opts.ignoreCase {
+		fileJv = lowercaseKeys(fileJv, path, "")
+	}
+
+	return fileJv, decoder, fileBytes, nil
+}
+
+// defaultInput returns the value given with --input-default, along with the
+// input format if one was given, or JSON otherwise.
+func (opts inputOptions) defaultInput() (*jq.Jv, formats.Encoding, error) {
+	defaultJv, err := jq.JvFromJSONString(opts.defaultValue)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid --input-default: %s", err)
+	}
+
+	decoder := formats.ByName["json"]
+	if named, ok := formats.ByName[strings.ToLower(opts.format)]; ok {
+		decoder = named
+	}
+	return defaultJv, decoder, nil
+}
+
+// replaceNullValues returns jv with every string contained in nullValues
+// replaced by null, at any depth.
+//
+// Consumes jv.
+func replaceNullValues(jv *jq.Jv, nullValues map[string]bool) *jq.Jv {
+	switch jv.Kind() {
+	case jq.JvKindString:
+		if str, _ := jv.StringValue(); nullValues[str] {
+			jv.Free()
+			return jq.JvNull()
+		}
+		return jv
+	case jq.JvKindArray:
+		result := jq.JvArray()
+		len := jv.Copy().ArrayLength()
+		for i := 0; i < len; i++ {
+			result = result.ArrayAppend(replaceNullValues(jv.Copy().ArrayGet(i), nullValues))
+		}
+		jv.Free()
+		return result
+	case jq.JvKindObject:
+		result := jq.JvObject()
+		jv.ObjectForEach(func(key string, value *jq.Jv) error {
+			result = result.ObjectSet(jq.JvFromString(key), replaceNullValues(value.Copy(), nullValues))
+			return nil
+		})
+		jv.Free()
+		return result
+	default:
+		return jv
+	}
+}
+
+// omitKeys returns jv without the keys contained in keys of every object it
+// contains, at any depth.
+//
+// Consumes jv.
+func omitKeys(jv *jq.Jv, keys map[string]bool) *jq.Jv {
+	switch jv.Kind() {
+	case jq.JvKindArray:
+		result := jq.JvArray()
+		len := jv.Copy().ArrayLength()
+		for i := 0; i < len; i++ {
+			result = result.ArrayAppend(omitKeys(jv.Copy().ArrayGet(i), keys))
+		}
+		jv.Free()
+		return result
+	case jq.JvKindObject:
+		result := jq.JvObject()
+		jv.ObjectForEach(func(key string, value *jq.Jv) error {
+			if !keys[key] {
+				result = result.ObjectSet(jq.JvFromString(key), omitKeys(value.Copy(), keys))
+			}
+			return nil
+		})
+		jv.Free()
+		return result
+	default:
+		return jv
+	}
+}
+
+// lowercaseKeys returns jv with the keys of every object it contains converted
+// to lowercase, at any depth. When two keys of an object differ only in case,
+// a warning is logged and the value of the latter is kept.
+//
+// Consumes jv.
+func lowercaseKeys(jv *jq.Jv, file, path string) *jq.Jv {
+	switch jv.Kind() {
+	case jq.JvKindArray:
+		result := jq.JvArray()
+		len := jv.Copy().ArrayLength()
+		for i := 0; i < len; i++ {
+			result = result.ArrayAppend(lowercaseKeys(jv.Copy().ArrayGet(i), file, fmt.Sprintf("%s[%d]", path, i)))
+		}
+		jv.Free()
+		return result
+	case jq.JvKindObject:
+		result := jq.JvObject()
+		originals := make(map[string]string)
+		jv.ObjectForEach(func(key string, value *jq.Jv) error {
+			lower := strings.ToLower(key)
+			if original, ok := originals[lower]; ok {
+				at := path
+				if at == "" {
+					at = "."
+				}
+				logrus.Warnf("keys %q and %q at %s in file at %s are both %q when ignoring case", original, key, at, file, lower)
+			}
+			originals[lower] = key
+			result = result.ObjectSet(jq.JvFromString(lower), lowercaseKeys(value.Copy(), file, path+"."+lower))
+			return nil
+		})
+		jv.Free()
+		return result
+	default:
+		return jv
+	}
+}
+
+// encoder determines the encoding for the output of a file that was decoded
+// with decoder.
+func (opts outputOptions) encoder(decoder formats.Encoding) (formats.Encoding, error) {
+	if opts.format == "auto" {
+		return decoder, nil
+	}
+
+	encoder, ok := formats.ByName[strings.ToLower(opts.format)]
+	if !ok {
+		return nil, fmt.Errorf("no supported format found named %s", opts.format)
+	}
+	return encoder, nil
+}
+
+// fileEncoder returns the encoder for writing back a modified copy of the file
+// fileBytes, which was decoded with decoder. TOML written back as TOML keeps
+// the kinds of its numbers; see formats.TOMLWithNumberKinds.
+func (opts outputOptions) fileEncoder(decoder formats.Encoding, fileBytes []byte) (formats.Encoding, error) {
+	encoder, err := opts.encoder(decoder)
+	if err != nil {
+		return nil, err
+	}
+	if decoder != formats.ByName["toml"] || encoder != decoder || fileBytes == nil {
+		return encoder, nil
+	}
+	return formats.TOMLWithNumberKinds(fileBytes)
+}
+
+// separator returns the line to print between consecutive documents encoded
+// with encoder, if the format has one.
+func (opts outputOptions) separator(encoder formats.Encoding) (string, bool) {
+	multi, ok := encoder.(formats.MultiDocumentEncoding)
+	if !ok || opts.noSeparator {
+		return "", false
+	}
+	return multi.DocumentSeparator(), true
+}
+
+// omit reports whether a result should not be printed at all.
+//
+// Does not consume jv.
+func (opts outputOptions) omit(jv *jq.Jv) bool {
+	if !opts.omitEmpty {
+		return false
+	}
+
+	switch jv.Kind() {
+	case jq.JvKindNull:
+		return true
+	case jq.JvKindArray:
+		return jv.Copy().ArrayLength() == 0
+	case jq.JvKindObject:
+		keys, _ := jv.SortedKeys()
+		return len(keys) == 0
+	default:
+		return false
+	}
+}
+
+// encode renders a result with encoder as it should be printed.
+//
+// Consumes jv.
+func (opts outputOptions) encode(jv *jq.Jv, encoder formats.Encoding) ([]byte, error) {
+	if opts.raw && opts.fieldSeparator != "" {
+		if fields, ok := joinFields(jv, opts.fieldSeparator); ok {
+			jv.Free()
+			return []byte(fields), nil
+		}
+	}
+
+	resultBytes := []byte(jv.Dump(jq.JvPrintNone))
+	output, err := encoder.UnmarshalJSONBytes(resultBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode jq program output as %s: %s", opts.format, err)
+	}
+
+	if opts.pretty {
+		output, err = encoder.PrettyPrint(output)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode jq program output as pretty %s: %s", opts.format, err)
+		}
+	}
+
+	if opts.raw {
+		output, err = encoder.Raw(output)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode jq program output as raw %s: %s", opts.format, err)
+		}
+	} else if opts.color {
+		output, err = encoder.Color(output)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode jq program output as color %s: %s", opts.format, err)
+		}
+	}
+
+	return output, nil
+}
+
+// encodeWithComments encodes jv as YAML in the same way as encode, but with
+// the comments of the values it shares with the input. See
+// commentedYAML.encode for i and n.
+//
+// Consumes jv.
+func (opts outputOptions) encodeWithComments(jv *jq.Jv, comments *commentedYAML, i, n int) ([]byte, error) {
+	if opts.raw && opts.fieldSeparator != "" {
+		if fields, ok := joinFields(jv, opts.fieldSeparator); ok {
+			jv.Free()
+			return []byte(fields), nil
+		}
+	}
+
+	output, err := comments.encode(jv, i, n)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.color && !opts.raw {
+		output, err = formats.ByName["yaml"].Color(output)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode jq program output as color yaml: %s", err)
+		}
+	}
+
+	return output, nil
+}
+
+// joinFields joins the elements of an array-typed jv with separator in the
+// same way as jq's `join`: strings are used as-is, null is empty and numbers
+// and booleans are converted to text. It returns false if jv is not an array
+// or contains arrays or objects.
+//
+// Does not consume jv.
+func joinFields(jv *jq.Jv, separator string) (string, bool) {
+	if jv.Kind() != jq.JvKindArray {
+		return "", false
+	}
+
+	len := jv.Copy().ArrayLength()
+	fields := make([]string, len)
+	for i := 0; i < len; i++ {
+		elem := jv.Copy().ArrayGet(i)
+		switch elem.Kind() {
+		case jq.JvKindString:
+			fields[i], _ = elem.StringValue()
+			elem.Free()
+		case jq.JvKindNull:
+			elem.Free()
+		case jq.JvKindNumber, jq.JvKindTrue, jq.JvKindFalse:
+			fields[i] = elem.Dump(jq.JvPrintNone)
+		default:
+			elem.Free()
+			return "", false
+		}
+	}
+	return strings.Join(fields, separator), true
+}
+
+// labelLines prefixes every line of output with prefix, dropping any trailing
+// newline so that no empty labeled line is printed.
+func labelLines(output []byte, prefix string) []byte {
+	trimmed := strings.TrimSuffix(string(output), "\n")
+	lines := strings.Split(trimmed, "\n")
+	for i, line := range lines {
+		lines[i] = prefix + line
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// charsets maps the names accepted by --input-encoding to their decoders.
+var charsets = map[string]encoding.Encoding{
+	"latin1":       charmap.ISO8859_1,
+	"iso-8859-1":   charmap.ISO8859_1,
+	"windows-1252": charmap.Windows1252,
+	"cp1252":       charmap.Windows1252,
+	"gbk":          simplifiedchinese.GBK,
+}
+
+// readFile reads the file at path, transcoding it from charset into UTF-8 so
+// that the bytes handed to the format decoders are always UTF-8.
+//
+// If maxSize is positive, files larger than maxSize bytes are rejected.
+func readFile(path, charset string, maxSize int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	var limited *io.LimitedReader
+	if maxSize > 0 {
+		// Allow reading one byte past the limit to detect oversized input.
+		limited = &io.LimitedReader{R: f, N: maxSize + 1}
+		r = limited
+	}
+
+	switch charset = strings.ToLower(charset); charset {
+	case "", "utf-8", "utf8":
+	default:
+		enc, ok := charsets[charset]
+		if !ok {
+			return nil, fmt.Errorf("unsupported input encoding %s", charset)
+		}
+		r = enc.NewDecoder().Reader(r)
+	}
+
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if limited != nil && limited.N == 0 {
+		return nil, fmt.Errorf("input is larger than the maximum of %d bytes", maxSize)
+	}
+	return b, nil
+}
+
+// transformInput pipes input through the shell command, returning its output.
+func transformInput(input []byte, command string) ([]byte, error) {
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stderr = os.Stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", command, err)
+	}
+	return output, nil
+}
+
+// parseFormatOptions parses a list of options in the form
+// "KEY=VALUE,KEY=VALUE".
+func parseFormatOptions(s string) (map[string]string, error) {
+	opts := make(map[string]string)
+	if s == "" {
+		return opts, nil
+	}
+
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("%q is not in the form KEY=VALUE", pair)
+		}
+		opts[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+
+	return opts, nil
+}
+
+// byteSizeSuffixes maps the suffixes accepted by parseByteSize to their
+// multipliers.
+var byteSizeSuffixes = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"KIB", 1 << 10},
+	{"MIB", 1 << 20},
+	{"GIB", 1 << 30},
+	{"KB", 1000},
+	{"MB", 1000 * 1000},
+	{"GB", 1000 * 1000 * 1000},
+	{"K", 1 << 10},
+	{"M", 1 << 20},
+	{"G", 1 << 30},
+	{"B", 1},
+}
+
+// parseByteSize parses a human-readable size such as "512", "10MB" or
+// "1GiB" into a number of bytes.
+func parseByteSize(size string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(size))
+	multiplier := int64(1)
+	for _, suffix := range byteSizeSuffixes {
+		if strings.HasSuffix(s, suffix.suffix) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, suffix.suffix))
+			multiplier = suffix.multiplier
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%q is not a valid size", size)
+	}
+	return n * multiplier, nil
+}
+
 func detectFormat(fileBytes []byte, path string) (formats.Encoding, bool) {
 	if ext := filepath.Ext(path); ext != "" {
 		if format, ok := formats.ByName[ext[1:]]; ok {
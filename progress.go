@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// progress reports how many of the input files have been processed on
+// stderr. When stderr is a terminal, a single status line is overwritten in
+// place; otherwise a line is printed for each completed file.
+//
+// A nil *progress reports nothing.
+type progress struct {
+	w         io.Writer
+	tty       bool
+	total     int
+	completed int
+}
+
+func newProgress(total int) *progress {
+	return &progress{
+		w:     os.Stderr,
+		tty:   terminal.IsTerminal(int(os.Stderr.Fd())),
+		total: total,
+	}
+}
+
+// start reports that the file named label is being processed.
+func (p *progress) start(label string) {
+	if p == nil || !p.tty {
+		return
+	}
+	fmt.Fprintf(p.w, "\r\x1b[K[%d/%d] processing %s", p.completed+1, p.total, label)
+}
+
+// clear removes the status line so that output can be written to the same
+// terminal.
+func (p *progress) clear() {
+	if p == nil || !p.tty {
+		return
+	}
+	fmt.Fprint(p.w, "\r\x1b[K")
+}
+
+// done reports that the file named label has been processed.
+func (p *progress) done(label string) {
+	if p == nil {
+		return
+	}
+	p.completed++
+	if !p.tty {
+		fmt.Fprintf(p.w, "[%d/%d] %s\n", p.completed, p.total, label)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jzelinskie/faq/formats"
+	"github.com/jzelinskie/faq/jq"
+)
+
+// runReduce folds every input file into a single value by evaluating the
+// --reduce program once per file, with the accumulator as its input and the
+// file bound to $x. The variables given with --arg and --argjson are bound in
+// both the program and the --reduce-init expression.
+func runReduce(cmd *cobra.Command, args []string, vars []variable, inOpts inputOptions, outOpts outputOptions) error {
+	program, _ := cmd.Flags().GetString("reduce")
+	program = bindVariables(program, vars)
+	initExpr, _ := cmd.Flags().GetString("reduce-init")
+	initExpr = bindVariables(initExpr, vars)
+	initPath, _ := cmd.Flags().GetString("reduce-init-file")
+	if initPath != "" && cmd.Flags().Changed("reduce-init") {
+		return errors.New("--reduce-init and --reduce-init-file cannot be used together")
+	}
+
+	paths, ok := pathArgs(args)
+	if !ok {
+		return fmt.Errorf("not enough arguments provided")
+	}
+	if len(args) == 0 {
+		outOpts.color = false
+	}
+
+	var initJv *jq.Jv
+	var decoder formats.Encoding
+	var err error
+	if initPath != "" {
+		initPath = os.ExpandEnv(initPath)
+		initJv, decoder, err = decodeFile(initPath, inOpts)
+		if err != nil {
+			return err
+		}
+		if initJv == nil {
+			initJv = jq.JvNull()
+		}
+	} else {
+		initJv, err = evalExpr(initExpr)
+		if err != nil {
+			return fmt.Errorf("failed to evaluate --reduce-init: %s", err)
+		}
+	}
+
+	files := jq.JvArray()
+	for _, path := range paths {
+		fileJv, fileDecoder, err := decodeFile(os.ExpandEnv(path), inOpts)
+		if err != nil {
+			initJv.Free()
+			files.Free()
+			return err
+		}
+
+		if fileJv == nil {
+			continue
+		}
+
+		if decoder == nil {
+			decoder = fileDecoder
+		}
+		files = files.ArrayAppend(fileJv)
+	}
+	defer files.Free()
+
+	if decoder == nil {
+		initJv.Free()
+		return nil
+	}
+
+	resultJv, err := files.Reduce(initJv, program)
+	if err != nil {
+		return fmt.Errorf("failed to execute --reduce program: %s", err)
+	}
+
+	if outOpts.omit(resultJv) {
+		resultJv.Free()
+		return nil
+	}
+
+	encoder, err := outOpts.encoder(decoder)
+	if err != nil {
+		resultJv.Free()
+		return err
+	}
+
+	output, err := outOpts.encode(resultJv, encoder)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(output))
+
+	return nil
+}
+
+// evalExpr evaluates a jq expression that takes no input, such as a literal,
+// returning its first result.
+func evalExpr(expr string) (*jq.Jv, error) {
+	libjq, err := jq.New()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize libjq: %s", err)
+	}
+	defer libjq.Close()
+
+	for _, err := range libjq.Compile(expr, jq.JvArray()) {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	results, err := libjq.Execute(jq.JvNull())
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("%s produced no results", expr)
+	}
+	for _, result := range results[1:] {
+		result.Free()
+	}
+	return results[0], nil
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/peterh/liner"
+
+	"github.com/jzelinskie/faq/formats"
+	"github.com/jzelinskie/faq/jq"
+)
+
+const replHelp = `Enter a jq program to run it against the loaded file.
+
+Commands:
+  :reload          re-read the file from disk
+  :format FORMAT   change the output format (e.g. json, yaml, auto)
+  :help            show this message
+  :quit            exit
+`
+
+// replHistoryPath returns the file used to persist REPL history between
+// sessions, or an empty string if there is no home directory.
+func replHistoryPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".faq_history")
+}
+
+// runREPL decodes the file at path once and then repeatedly prompts for jq
+// programs to run against it until the user quits. Each program has vars bound.
+func runREPL(path string, vars []variable, inOpts inputOptions, outOpts outputOptions) error {
+	fileJv, decoder, err := decodeFile(path, inOpts)
+	if err != nil {
+		return err
+	}
+	if fileJv == nil {
+		fileJv, decoder = jq.JvNull(), formats.ByName["json"]
+	}
+	defer func() { fileJv.Free() }()
+
+	libjq, err := jq.New()
+	if err != nil {
+		return fmt.Errorf("failed to initialize libjq: %s", err)
+	}
+	defer libjq.Close()
+
+	line := liner.NewLiner()
+	defer line.Close()
+	line.SetCtrlCAborts(true)
+
+	historyPath := replHistoryPath()
+	if historyPath != "" {
+		if f, err := os.Open(historyPath); err == nil {
+			line.ReadHistory(f)
+			f.Close()
+		}
+		defer func() {
+			if f, err := os.Create(historyPath); err == nil {
+				line.WriteHistory(f)
+				f.Close()
+			}
+		}()
+	}
+
+	for {
+		input, err := line.Prompt("faq> ")
+		if err == liner.ErrPromptAborted {
+			continue
+		} else if err == io.EOF {
+			fmt.Println()
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		input = strings.TrimSpace(input)
+		if input == "" {
+			continue
+		}
+		line.AppendHistory(input)
+
+		if strings.HasPrefix(input, ":") {
+			fields := strings.Fields(input)
+			switch fields[0] {
+			case ":quit", ":q":
+				return nil
+			case ":help":
+				fmt.Print(replHelp)
+			case ":reload":
+				reloadedJv, reloadedDecoder, err := decodeFile(path, inOpts)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					continue
+				}
+				if reloadedJv == nil {
+					reloadedJv, reloadedDecoder = jq.JvNull(), formats.ByName["json"]
+				}
+				fileJv.Free()
+				fileJv, decoder = reloadedJv, reloadedDecoder
+			case ":format":
+				if len(fields) != 2 {
+					fmt.Fprintln(os.Stderr, "usage: :format FORMAT")
+					continue
+				}
+				format := strings.ToLower(fields[1])
+				if _, ok := formats.ByName[format]; !ok && format != "auto" {
+					fmt.Fprintf(os.Stderr, "no supported format found named %s\n", fields[1])
+					continue
+				}
+				outOpts.format = format
+			default:
+				fmt.Fprintf(os.Stderr, "unknown command %s, try :help\n", fields[0])
+			}
+			continue
+		}
+
+		if err := runREPLProgram(libjq, bindVariables(input, vars), fileJv.Copy(), decoder, outOpts); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+}
+
+// runREPLProgram compiles program and prints its results for fileJv.
+//
+// Consumes fileJv.
+func runREPLProgram(libjq *jq.Jq, program string, fileJv *jq.Jv, decoder formats.Encoding, outOpts outputOptions) error {
+	for _, err := range libjq.Compile(program, jq.JvArray()) {
+		if err != nil {
+			fileJv.Free()
+			return fmt.Errorf("failed to compile jq program: %s", err)
+		}
+	}
+
+	resultJvs, err := libjq.Execute(fileJv)
+	if err != nil {
+		return fmt.Errorf("failed to execute jq program: %s", err)
+	}
+
+	encoder, err := outOpts.encoder(decoder)
+	if err != nil {
+		return err
+	}
+
+	for _, resultJv := range resultJvs {
+		if outOpts.omit(resultJv) {
+			resultJv.Free()
+			continue
+		}
+
+		output, err := outOpts.encode(resultJv, encoder)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(output))
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jzelinskie/faq/formats"
+	"github.com/jzelinskie/faq/jq"
+)
+
+func newSchemaCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema [flags] [files...]",
+		Short: "infer a JSON Schema from example files",
+		Long: `schema prints a JSON Schema (draft-07) describing every file given as an example.
+
+A field whose type varies between examples is given each of the types seen, and the
+elements of arrays are described by every type seen in any of them. Fields that
+are missing from some examples are not required.`,
+		DisableFlagsInUseLine: true,
+		RunE:                  runSchemaCmdFunc,
+	}
+}
+
+func runSchemaCmdFunc(cmd *cobra.Command, args []string) error {
+	inOpts, err := newInputOptions(cmd)
+	if err != nil {
+		return err
+	}
+	outOpts := newOutputOptions(cmd)
+
+	paths, ok := pathArgs(args)
+	if !ok {
+		return fmt.Errorf("not enough arguments provided")
+	}
+	if len(args) == 0 {
+		outOpts.color = false
+	}
+
+	root := &schemaNode{}
+	var decoder formats.Encoding
+	for _, path := range paths {
+		fileJv, fileDecoder, err := decodeFile(os.ExpandEnv(path), inOpts)
+		if err != nil {
+			return err
+		}
+
+		if fileJv == nil {
+			continue
+		}
+
+		if decoder == nil {
+			decoder = fileDecoder
+		}
+		root.observe(fileJv)
+		fileJv.Free()
+	}
+
+	if decoder == nil {
+		return nil
+	}
+
+	encoder, err := outOpts.encoder(decoder)
+	if err != nil {
+		return err
+	}
+
+	schema := jq.JvObject().ObjectSet(jq.JvFromString("$schema"), jq.JvFromString("http://json-schema.org/draft-07/schema#"))
+	output, err := outOpts.encode(root.schema(schema), encoder)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(output))
+
+	return nil
+}
+
+// schemaTypeOrder is the order in which the types of a schema are listed.
+var schemaTypeOrder = []string{"null", "boolean", "integer", "number", "string", "array", "object"}
+
+// schemaNode accumulates the values seen at one location across every
+// example.
+type schemaNode struct {
+	types map[string]bool
+
+	// objects is the number of objects seen, and keys the number of those in
+	// which each key appeared, in the order the keys were first seen.
+	objects    int
+	keys       []string
+	keyCounts  map[string]int
+	properties map[string]*schemaNode
+
+	items *schemaNode
+}
+
+// observe records jv as an example of the value at n.
+//
+// Does not consume jv.
+func (n *schemaNode) observe(jv *jq.Jv) {
+	if n.types == nil {
+		n.types = make(map[string]bool)
+	}
+
+	switch jv.Kind() {
+	case jq.JvKindNull:
+		n.types["null"] = true
+	case jq.JvKindTrue, jq.JvKindFalse:
+		n.types["boolean"] = true
+	case jq.JvKindNumber:
+		if f, _ := jv.ToFloat64(); f == math.Trunc(f) && !math.IsInf(f, 0) {
+			n.types["integer"] = true
+		} else {
+			n.types["number"] = true
+		}
+	case jq.JvKindString:
+		n.types["string"] = true
+	case jq.JvKindArray:
+		n.types["array"] = true
+		if n.items == nil {
+			n.items = &schemaNode{}
+		}
+		len := jv.Copy().ArrayLength()
+		for i := 0; i < len; i++ {
+			elem := jv.Copy().ArrayGet(i)
+			n.items.observe(elem)
+			elem.Free()
+		}
+	case jq.JvKindObject:
+		n.types["object"] = true
+		if n.properties == nil {
+			n.properties = make(map[string]*schemaNode)
+			n.keyCounts = make(map[string]int)
+		}
+		n.objects++
+		jv.ObjectForEach(func(key string, value *jq.Jv) error {
+			prop, ok := n.properties[key]
+			if !ok {
+				prop = &schemaNode{}
+				n.properties[key] = prop
+				n.keys = append(n.keys, key)
+			}
+			n.keyCounts[key]++
+			prop.observe(value)
+			return nil
+		})
+	}
+}
+
+// schema adds the keywords describing n to the object-typed schema.
+//
+// Consumes schema.
+func (n *schemaNode) schema(schema *jq.Jv) *jq.Jv {
+	var types []string
+	for _, t := range schemaTypeOrder {
+		// Integers are numbers, so only list integer if it's all that was seen.
+		if n.types[t] && !(t == "integer" && n.types["number"]) {
+			types = append(types, t)
+		}
+	}
+
+	switch len(types) {
+	case 0:
+	case 1:
+		schema = schema.ObjectSet(jq.JvFromString("type"), jq.JvFromString(types[0]))
+	default:
+		schema = schema.ObjectSet(jq.JvFromString("type"), jq.JvFromStringSlice(types))
+	}
+
+	if n.items != nil && n.items.types != nil {
+		schema = schema.ObjectSet(jq.JvFromString("items"), n.items.schema(jq.JvObject()))
+	}
+
+	if n.objects > 0 {
+		properties := jq.JvObject()
+		var required []string
+		for _, key := range n.keys {
+			properties = properties.ObjectSet(jq.JvFromString(key), n.properties[key].schema(jq.JvObject()))
+			if n.keyCounts[key] == n.objects {
+				required = append(required, key)
+			}
+		}
+		schema = schema.ObjectSet(jq.JvFromString("properties"), properties)
+		if len(required) > 0 {
+			schema = schema.ObjectSet(jq.JvFromString("required"), jq.JvFromStringSlice(required))
+		}
+	}
+
+	return schema
+}
@@ -0,0 +1,552 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/jzelinskie/faq/jq"
+)
+
+// schemaLinter performs a best-effort check of the paths accessed by a jq
+// program against a JSON Schema describing its input.
+//
+// Only paths whose input can be determined without evaluating the program are
+// checked: paths at the start of the program, after a pipe following a plain
+// path, within `select(...)` and friends, and within the filter of `map(...)`
+// and friends. Anything else is skipped rather than guessed at.
+type schemaLinter struct {
+	root     interface{}
+	warnings []string
+}
+
+// lintProgram returns a warning for each path in program that accesses a field
+// not defined by schema or indexes a value of the wrong type, or an error if
+// program doesn't compile.
+func lintProgram(program string, schema interface{}) ([]string, error) {
+	libjq, err := jq.New()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize libjq: %s", err)
+	}
+	defer libjq.Close()
+
+	// Only programs that libjq accepts are scanned, so that the scan never has
+	// to make sense of unbalanced brackets or unterminated strings.
+	if errs := libjq.Compile(program, jq.JvArray()); len(errs) > 0 {
+		return nil, errs[0]
+	}
+
+	l := &schemaLinter{root: schema}
+	l.expr(program, &schemaContext{schema: schema})
+	return l.warnings, nil
+}
+
+// schemaContext is the schema of the input to an expression, along with the
+// path it was reached by for use in warnings.
+//
+// A nil *schemaContext means that the input is unknown.
+type schemaContext struct {
+	path   string
+	schema interface{}
+}
+
+// sameInputFuncs are the builtins whose filter arguments are applied to their
+// own input.
+var sameInputFuncs = map[string]bool{
+	"select": true, "del": true, "path": true, "paths": true,
+	"has": true, "contains": true, "test": true, "error": true,
+}
+
+// elementFuncs are the builtins whose filter arguments are applied to each
+// element of their input.
+var elementFuncs = map[string]bool{
+	"map": true, "sort_by": true, "group_by": true, "unique_by": true,
+	"min_by": true, "max_by": true,
+}
+
+// expr lints a complete expression, returning the context of its output if it
+// is a plain path.
+func (l *schemaLinter) expr(expr string, ctx *schemaContext) *schemaContext {
+	for _, segment := range splitTopLevel(expr, '|') {
+		ctx = l.segment(strings.TrimSpace(segment), ctx)
+	}
+	return ctx
+}
+
+// segment lints an expression containing no top-level pipes, returning the
+// context of its output if it is a plain path or binds a variable.
+func (l *schemaLinter) segment(seg string, ctx *schemaContext) *schemaContext {
+	var out *schemaContext
+	pure := false
+	binds := false
+
+	for i := 0; i < len(seg); {
+		c := seg[i]
+		switch {
+		case c == '"':
+			i = skipString(seg, i)
+
+		case c == '#':
+			for i < len(seg) && seg[i] != '\n' {
+				i++
+			}
+
+		case c == '|' && i+1 < len(seg) && seg[i+1] == '=':
+			// The right-hand side of an update has the value being updated
+			// as its input.
+			ctx = nil
+			i += 2
+
+		case c == '.' && startsTerm(seg, i):
+			if i+1 < len(seg) && seg[i+1] == '.' {
+				// Recursive descent could be anywhere in the schema.
+				i += 2
+				continue
+			}
+			components, end, ok := parsePath(seg, i)
+			if ok && ctx != nil {
+				out = l.check(ctx, components)
+			}
+			pure = i == 0 && end == len(seg) && ok
+			i = end
+
+		case c == '(' || c == '[' || c == '{':
+			end := matchingBracket(seg, i)
+			inner := seg[i+1 : end]
+			switch {
+			case c == '{':
+				l.object(inner, ctx)
+			case c == '(' && i > 0 && isIdentByte(seg[i-1]):
+				l.call(precedingIdent(seg, i), inner, ctx)
+			case c == '(':
+				// Groupings after a variable, such as the body of
+				// `reduce .[] as $x (...)`, have some other input.
+				if !strings.Contains(seg[:i], "$") {
+					l.expr(inner, ctx)
+				}
+			case c == '[':
+				l.expr(inner, ctx)
+			}
+			i = end + 1
+
+		case isIdentByte(c) && (i == 0 || !isIdentByte(seg[i-1])):
+			start := i
+			for i < len(seg) && isIdentByte(seg[i]) {
+				i++
+			}
+			if seg[start:i] == "as" {
+				binds = true
+			}
+
+		default:
+			i++
+		}
+	}
+
+	if binds {
+		return ctx
+	}
+	if pure {
+		return out
+	}
+	return nil
+}
+
+// call lints the arguments of a call to the builtin name.
+func (l *schemaLinter) call(name, args string, ctx *schemaContext) {
+	var argCtx *schemaContext
+	switch {
+	case sameInputFuncs[name]:
+		argCtx = ctx
+	case elementFuncs[name] && ctx != nil:
+		argCtx = l.elements(ctx)
+	}
+
+	for _, arg := range splitTopLevel(args, ';') {
+		l.expr(strings.TrimSpace(arg), argCtx)
+	}
+}
+
+// object lints the entries of an object construction such as `{a: .x, b}`.
+func (l *schemaLinter) object(entries string, ctx *schemaContext) {
+	for _, entry := range splitTopLevel(entries, ',') {
+		entry = strings.TrimSpace(entry)
+		kv := splitTopLevel(entry, ':')
+		if len(kv) > 1 {
+			l.expr(strings.Join(kv[1:], ":"), ctx)
+		} else if ctx != nil && identRegexp.MatchString(entry) {
+			l.check(ctx, []interface{}{entry})
+		}
+	}
+}
+
+// elements returns the context of the values produced by `.[]` on ctx.
+func (l *schemaLinter) elements(ctx *schemaContext) *schemaContext {
+	return l.check(ctx, []interface{}{iterate{}})
+}
+
+// iterate is the path component for `[]`.
+type iterate struct{}
+
+// check walks components through the schema in ctx, recording a warning at the
+// first component that cannot be applied. It returns the context that the path
+// reaches, or nil if it cannot be determined.
+func (l *schemaLinter) check(ctx *schemaContext, components []interface{}) *schemaContext {
+	path, schema := ctx.path, ctx.schema
+	for _, component := range components {
+		obj := l.resolve(schema)
+		if obj == nil {
+			return nil
+		}
+		types := schemaTypes(obj)
+
+		switch c := component.(type) {
+		case string:
+			path += "." + c
+			if types != nil && !types["object"] {
+				l.warn(path, "cannot index %s with %q", typeList(types), c)
+				return nil
+			}
+			next, defined, known := property(obj, c)
+			if !known {
+				return nil
+			}
+			if !defined {
+				l.warn(path, "%q is not defined in the input schema", c)
+				return nil
+			}
+			schema = next
+
+		case int:
+			path += fmt.Sprintf("[%d]", c)
+			if types != nil && !types["array"] {
+				l.warn(path, "cannot index %s with a number", typeList(types))
+				return nil
+			}
+			items, ok := obj["items"].(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			schema = items
+
+		case iterate:
+			path += "[]"
+			switch {
+			case types != nil && !types["array"] && !types["object"]:
+				l.warn(path, "cannot iterate over %s", typeList(types))
+				return nil
+			case types["array"] && !types["object"]:
+				items, ok := obj["items"].(map[string]interface{})
+				if !ok {
+					return nil
+				}
+				schema = items
+			case types["object"] && !types["array"]:
+				additional, ok := obj["additionalProperties"].(map[string]interface{})
+				if !ok {
+					return nil
+				}
+				schema = additional
+			default:
+				return nil
+			}
+		}
+	}
+	return &schemaContext{path: path, schema: schema}
+}
+
+func (l *schemaLinter) warn(path, format string, args ...interface{}) {
+	if path == "" {
+		path = "."
+	}
+	l.warnings = append(l.warnings, path+": "+fmt.Sprintf(format, args...))
+}
+
+// resolve follows any local $ref in schema, returning nil for schemas that
+// don't constrain their value, such as `true`, or whose $ref can't be
+// followed.
+func (l *schemaLinter) resolve(schema interface{}) map[string]interface{} {
+	for i := 0; i < 32; i++ {
+		obj, ok := schema.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		ref, ok := obj["$ref"].(string)
+		if !ok {
+			return obj
+		}
+		if !strings.HasPrefix(ref, "#") {
+			return nil
+		}
+		schema = l.pointer(strings.TrimPrefix(ref, "#"))
+	}
+	return nil
+}
+
+// pointer returns the value at a JSON Pointer within the root schema.
+func (l *schemaLinter) pointer(ptr string) interface{} {
+	cur := l.root
+	if ptr == "" {
+		return cur
+	}
+	for _, token := range strings.Split(strings.TrimPrefix(ptr, "/"), "/") {
+		token = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+		obj, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		cur = obj[token]
+	}
+	return cur
+}
+
+// property looks up name within an object schema. known is false if the
+// schema doesn't say which properties are allowed.
+func property(obj map[string]interface{}, name string) (schema interface{}, defined, known bool) {
+	if props, ok := obj["properties"].(map[string]interface{}); ok {
+		if prop, ok := props[name]; ok {
+			return prop, true, true
+		}
+	}
+	if patterns, ok := obj["patternProperties"].(map[string]interface{}); ok {
+		for pattern, prop := range patterns {
+			if re, err := regexp.Compile(pattern); err == nil && re.MatchString(name) {
+				return prop, true, true
+			}
+		}
+	}
+
+	switch additional := obj["additionalProperties"].(type) {
+	case map[string]interface{}:
+		return additional, true, true
+	case bool:
+		if additional {
+			return nil, true, true
+		}
+		return nil, false, true
+	}
+
+	// Without additionalProperties, undeclared properties are allowed, but a
+	// schema listing its properties almost always means to list all of them.
+	for _, combinator := range []string{"allOf", "anyOf", "oneOf"} {
+		if _, ok := obj[combinator]; ok {
+			return nil, false, false
+		}
+	}
+	if _, ok := obj["properties"]; ok {
+		return nil, false, true
+	}
+	return nil, false, false
+}
+
+// schemaTypes returns the set of types allowed by a schema, or nil if it
+// doesn't say.
+func schemaTypes(obj map[string]interface{}) map[string]bool {
+	switch t := obj["type"].(type) {
+	case string:
+		return map[string]bool{t: true}
+	case []interface{}:
+		types := make(map[string]bool, len(t))
+		for _, elem := range t {
+			if s, ok := elem.(string); ok {
+				types[s] = true
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+func typeList(types map[string]bool) string {
+	var names []string
+	for _, name := range []string{"null", "boolean", "integer", "number", "string", "array", "object"} {
+		if types[name] {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, " or ")
+}
+
+var identRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+// startsTerm reports whether the "." at seg[i] begins a path applied to the
+// input, rather than continuing a path on some other value as in `$x.a` or
+// `(.a).b`, or being part of a number.
+func startsTerm(seg string, i int) bool {
+	for j := i - 1; j >= 0; j-- {
+		switch c := seg[j]; {
+		case c == ' ' || c == '\t' || c == '\n':
+			continue
+		case isIdentByte(c):
+			// Keywords such as `then` are followed by a new term.
+			return isKeyword(precedingIdent(seg, j+1))
+		case c == ')' || c == ']' || c == '}' || c == '"':
+			return false
+		default:
+			return true
+		}
+	}
+	return true
+}
+
+func isKeyword(word string) bool {
+	switch word {
+	case "if", "then", "elif", "else", "and", "or", "not", "as", "reduce", "foreach", "try", "catch", "def":
+		return true
+	}
+	return false
+}
+
+// precedingIdent returns the identifier immediately before seg[i].
+func precedingIdent(seg string, i int) string {
+	j := i
+	for j > 0 && isIdentByte(seg[j-1]) {
+		j--
+	}
+	return seg[j:i]
+}
+
+// parsePath parses the path beginning with the "." at seg[i], returning its
+// components and the index just after it. ok is false if the path contains
+// anything other than fields, numeric indexes and iteration.
+func parsePath(seg string, i int) (components []interface{}, end int, ok bool) {
+	first := true
+	for i < len(seg) {
+		switch {
+		case seg[i] == '?':
+			i++
+
+		case seg[i] == '.' && (first || i+1 < len(seg) && seg[i+1] != '.'):
+			i++
+			first = false
+			if i < len(seg) && seg[i] == '"' {
+				end := skipString(seg, i)
+				name, err := strconv.Unquote(seg[i:end])
+				if err != nil {
+					return components, end, false
+				}
+				components = append(components, name)
+				i = end
+				continue
+			}
+			start := i
+			for i < len(seg) && isIdentByte(seg[i]) && seg[i] != '$' {
+				i++
+			}
+			if start != i {
+				components = append(components, seg[start:i])
+			}
+
+		case seg[i] == '[':
+			end := matchingBracket(seg, i)
+			inner := strings.TrimSpace(seg[i+1 : end])
+			if inner == "" {
+				components = append(components, iterate{})
+			} else if n, err := strconv.Atoi(inner); err == nil && n >= 0 {
+				components = append(components, n)
+			} else if name, err := strconv.Unquote(inner); err == nil && strings.HasPrefix(inner, `"`) {
+				components = append(components, name)
+			} else {
+				return components, end + 1, false
+			}
+			i = end + 1
+
+		default:
+			return components, i, true
+		}
+	}
+	return components, i, true
+}
+
+// skipString returns the index just after the string literal starting at
+// seg[i].
+func skipString(seg string, i int) int {
+	for j := i + 1; j < len(seg); j++ {
+		switch seg[j] {
+		case '\\':
+			j++
+		case '"':
+			return j + 1
+		}
+	}
+	return len(seg)
+}
+
+// matchingBracket returns the index of the bracket closing the one at seg[i],
+// or the end of seg if it is unbalanced.
+func matchingBracket(seg string, i int) int {
+	depth := 0
+	for j := i; j < len(seg); j++ {
+		switch seg[j] {
+		case '"':
+			j = skipString(seg, j) - 1
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+	return len(seg)
+}
+
+// splitTopLevel splits s on sep wherever it appears outside of brackets and
+// string literals. A "|" that is part of "//" or "|=" is not split on.
+func splitTopLevel(s string, sep byte) []string {
+	var parts []string
+	depth, start := 0, 0
+	for j := 0; j < len(s); j++ {
+		switch c := s[j]; {
+		case c == '"':
+			j = skipString(s, j) - 1
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			depth--
+		case c == sep && depth == 0:
+			if sep == '|' && j+1 < len(s) && s[j+1] == '=' {
+				continue
+			}
+			parts = append(parts, s[start:j])
+			start = j + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// warnSchemaMismatches logs a warning for each path in program that doesn't
+// match the JSON Schema at schemaPath, or returns an error if program doesn't
+// compile. The schema may be in any format that can be detected automatically.
+func warnSchemaMismatches(program, schemaPath string) error {
+	schemaJv, _, err := decodeFile(schemaPath, inputOptions{format: "auto", encoding: "utf-8"})
+	if err != nil {
+		return err
+	}
+	if schemaJv == nil {
+		return fmt.Errorf("input schema at %s is empty", schemaPath)
+	}
+
+	var schema interface{}
+	if err := json.Unmarshal([]byte(schemaJv.Dump(jq.JvPrintNone)), &schema); err != nil {
+		return fmt.Errorf("failed to parse input schema at %s: %s", schemaPath, err)
+	}
+
+	warnings, err := lintProgram(program, schema)
+	if err != nil {
+		return fmt.Errorf("failed to compile jq program: %s", err)
+	}
+	for _, warning := range warnings {
+		logrus.Warnf("%s", warning)
+	}
+	return nil
+}
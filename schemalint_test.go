@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const lintSchema = `{
+	"type": "object",
+	"properties": {
+		"name": {"type": "string"},
+		"spec": {
+			"type": "object",
+			"properties": {
+				"replicas": {"type": "integer"},
+				"containers": {
+					"type": "array",
+					"items": {
+						"type": "object",
+						"properties": {
+							"image": {"type": "string"},
+							"ports": {"type": "array", "items": {"type": "integer"}}
+						}
+					}
+				}
+			}
+		},
+		"labels": {"type": "object", "additionalProperties": {"type": "string"}},
+		"meta": {"$ref": "#/definitions/meta"}
+	},
+	"definitions": {
+		"meta": {"type": "object", "properties": {"owner": {"type": "string"}}}
+	}
+}`
+
+func TestLintProgram(t *testing.T) {
+	var schema interface{}
+	if err := json.Unmarshal([]byte(lintSchema), &schema); err != nil {
+		t.Fatalf("failed to parse schema: %s", err)
+	}
+
+	var table = []struct {
+		name     string
+		program  string
+		warnings []string
+	}{
+		// Paths.
+		{"Defined", `.spec.replicas`, nil},
+		{"Undefined", `.spec.replica`, []string{`.spec.replica: "replica" is not defined in the input schema`}},
+		{"IndexString", `.name.first`, []string{`.name.first: cannot index string with "first"`}},
+		{"IndexStringWithNumber", `.name[0]`, []string{`.name[0]: cannot index string with a number`}},
+		{"Iterate", `.spec.containers[].image`, nil},
+		{"IterateUndefined", `.spec.containers[].tag`, []string{`.spec.containers[].tag: "tag" is not defined in the input schema`}},
+		{"Brackets", `.["spec"]["replicaz"]`, []string{`.spec.replicaz: "replicaz" is not defined in the input schema`}},
+		{"Quoted", `.spec."replicas"`, nil},
+		{"Optional", `.spec.replicas?`, nil},
+		{"Ref", `.meta.owner`, nil},
+		{"RefUndefined", `.meta.ownr`, []string{`.meta.ownr: "ownr" is not defined in the input schema`}},
+		{"AdditionalProperties", `.labels.anything`, nil},
+		{"Alternative", `.name // .nam`, []string{`.nam: "nam" is not defined in the input schema`}},
+
+		// Pipes.
+		{"Pipe", `.spec | .replicaz`, []string{`.spec.replicaz: "replicaz" is not defined in the input schema`}},
+		{"PipeIterate", `.spec.containers[] | .ports[0]`, nil},
+		{"PipeAfterFunction", `.spec | keys | .foo`, nil},
+
+		// select, map and friends.
+		{"Select", `.spec.containers[] | select(.imag == "x")`, []string{`.spec.containers[].imag: "imag" is not defined in the input schema`}},
+		{"Map", `.spec.containers | map(.ports[0], .tag)`, []string{`.spec.containers[].tag: "tag" is not defined in the input schema`}},
+		{"SortBy", `.spec.containers | sort_by(.image)`, nil},
+
+		// reduce.
+		{"ReduceSource", `reduce .spec.containerz[] as $c (0; . + 1)`, []string{`.spec.containerz: "containerz" is not defined in the input schema`}},
+		{"ReduceBody", `reduce .spec.containers[] as $c (0; . + ($c.ports | length))`, nil},
+		{"ReduceBodyAccumulator", `reduce .spec.containers[] as $c ({}; .total += 1)`, nil},
+
+		// Object construction.
+		{"Object", `{n: .name, r: .spec.replicaz}`, []string{`.spec.replicaz: "replicaz" is not defined in the input schema`}},
+		{"ObjectShorthand", `{name, nam}`, []string{`.nam: "nam" is not defined in the input schema`}},
+		{"ObjectQuotedKey", `{"spec.replicaz": .name}`, nil},
+
+		// Things that look like undefined paths but aren't paths of the input.
+		{"Variable", `.spec as $s | $s.foo`, nil},
+		{"BoundVariable", bindVariables(`.name`, []variable{{name: "x", value: `{"a":".foo"}`}}), nil},
+		{"String", `"x.foo" | .`, nil},
+		{"Number", `.spec.replicas * 1.5`, nil},
+		{"Comment", `.name # .bogus`, nil},
+		{"RecursiveDescent", `.. | .foo?`, nil},
+		{"Update", `.spec |= .foo`, nil},
+		{"Grouping", `(.spec | keys) | .[0]`, nil},
+		{"FunctionResult", `.spec | to_entries | .[0].key`, nil},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings, err := lintProgram(tt.program, schema)
+			if err != nil {
+				t.Fatalf("lintProgram() failed: %s", err)
+			}
+			if !reflect.DeepEqual(warnings, tt.warnings) {
+				t.Errorf("lintProgram() got: %q, want: %q", warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestLintProgramCompileError(t *testing.T) {
+	for _, program := range []string{`.spec |`, `.name[`, `"unterminated`, `$undefined`} {
+		if _, err := lintProgram(program, map[string]interface{}{}); err == nil {
+			t.Errorf("lintProgram(%q) did not return an error", program)
+		}
+	}
+}
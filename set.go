@@ -0,0 +1,217 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
+
+	"github.com/jzelinskie/faq/jq"
+)
+
+func newSetCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set [flags] [path=value...] [file]",
+		Short: "update the values at paths",
+		Long: `set updates the value at each path in a file, such as ".metadata.name=my-pod", and
+prints the result in the format of the input.
+
+Values are parsed as JSON when they are valid JSON and used as strings otherwise,
+so ".replicas=3" sets a number while ".name=web" sets a string.`,
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.MinimumNArgs(1),
+		RunE:                  runSetCmdFunc,
+	}
+
+	cmd.Flags().BoolP("in-place", "i", false, "write the result back to the file instead of printing it")
+
+	return cmd
+}
+
+// splitAssignment splits a PATH=VALUE argument on its first "=" that is
+// outside brackets and string literals, so that paths such as .["a=b"] can be
+// given.
+func splitAssignment(arg string) (path, value string, ok bool) {
+	i := assignmentIndex(arg)
+	if i < 0 || !strings.HasPrefix(arg, ".") {
+		return "", "", false
+	}
+	return arg[:i], arg[i+1:], true
+}
+
+// assignmentIndex returns the index of the first "=" in arg that is outside
+// brackets and string literals and isn't part of an operator such as "==" or
+// "|=", or -1 if there isn't one.
+func assignmentIndex(arg string) int {
+	depth := 0
+	inString := false
+
+	// interpolations holds the depth outside of each string interpolation,
+	// such as "\(.a)", that the scan is inside of.
+	var interpolations []int
+
+	for i := 0; i < len(arg); i++ {
+		c := arg[i]
+		if inString {
+			switch c {
+			case '\\':
+				if i+1 < len(arg) && arg[i+1] == '(' {
+					interpolations = append(interpolations, depth)
+					depth++
+					inString = false
+				}
+				i++
+			case '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+			if n := len(interpolations); n > 0 && interpolations[n-1] == depth {
+				interpolations = interpolations[:n-1]
+				inString = true
+			}
+		case '=':
+			if i+1 < len(arg) && arg[i+1] == '=' {
+				i++
+				continue
+			}
+			if depth == 0 && (i == 0 || !strings.ContainsRune("!<>|+-*/%", rune(arg[i-1]))) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// parseValue parses value as JSON, falling back to a string if it is not
+// valid JSON.
+func parseValue(value string) *jq.Jv {
+	jv, err := jq.JvFromJSONString(value)
+	if err != nil {
+		return jq.JvFromString(value)
+	}
+	return jv
+}
+
+func runSetCmdFunc(cmd *cobra.Command, args []string) error {
+	inOpts, err := newInputOptions(cmd)
+	if err != nil {
+		return err
+	}
+	outOpts := newOutputOptions(cmd)
+	inPlace, _ := cmd.Flags().GetBool("in-place")
+
+	// The file is the last argument unless every argument is an assignment
+	// and there is input on stdin.
+	path := "/dev/stdin"
+	assignmentArgs := args
+	_, _, lastIsAssignment := splitAssignment(args[len(args)-1])
+	if !lastIsAssignment || terminal.IsTerminal(int(os.Stdin.Fd())) {
+		path = os.ExpandEnv(args[len(args)-1])
+		assignmentArgs = args[:len(args)-1]
+	} else {
+		outOpts.color = false
+	}
+
+	if len(assignmentArgs) == 0 {
+		return errors.New("no path=value arguments provided")
+	}
+	if inPlace && path == "/dev/stdin" {
+		return errors.New("--in-place requires a file")
+	}
+
+	// The values are passed in alongside the document rather than written into
+	// the program so that they don't need to be escaped. They aren't bound as
+	// program arguments because libjq 1.6 double frees when converting an
+	// array of arguments.
+	filters := make([]string, len(assignmentArgs))
+	values := jq.JvArray()
+	for i, arg := range assignmentArgs {
+		target, value, ok := splitAssignment(arg)
+		if !ok {
+			values.Free()
+			return fmt.Errorf("%q is not in the form .PATH=VALUE", arg)
+		}
+
+		filters[i] = fmt.Sprintf("setpath(path(%s); $values[%d])", target, i)
+		values = values.ArrayAppend(parseValue(value))
+	}
+	program := ".[1] as $values | .[0] | " + strings.Join(filters, " | ")
+
+	fileJv, decoder, fileBytes, err := decodeFileBytes(path, inOpts)
+	if err != nil {
+		values.Free()
+		return err
+	}
+	if fileJv == nil {
+		values.Free()
+		return fmt.Errorf("file at %s is empty", path)
+	}
+	input := jq.JvArray().ArrayAppend(fileJv).ArrayAppend(values)
+
+	libjq, err := jq.New()
+	if err != nil {
+		input.Free()
+		return fmt.Errorf("failed to initialize libjq: %s", err)
+	}
+	defer libjq.Close()
+
+	for _, err := range libjq.Compile(program, jq.JvArray()) {
+		if err != nil {
+			input.Free()
+			return fmt.Errorf("invalid path: %s", err)
+		}
+	}
+
+	resultJvs, err := libjq.Execute(input)
+	if err != nil {
+		return fmt.Errorf("failed to update file at %s: %s", path, err)
+	}
+	if len(resultJvs) != 1 {
+		for _, resultJv := range resultJvs {
+			resultJv.Free()
+		}
+		return fmt.Errorf("paths must each refer to a single value")
+	}
+
+	encoder, err := outOpts.fileEncoder(decoder, fileBytes)
+	if err != nil {
+		resultJvs[0].Free()
+		return err
+	}
+
+	if inPlace {
+		outOpts.raw, outOpts.color = false, false
+	}
+
+	output, err := outOpts.encode(resultJvs[0], encoder)
+	if err != nil {
+		return err
+	}
+
+	if !inPlace {
+		fmt.Println(string(output))
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !strings.HasSuffix(string(output), "\n") {
+		output = append(output, '\n')
+	}
+	return ioutil.WriteFile(path, output, info.Mode())
+}
@@ -0,0 +1,42 @@
+package main
+
+import "testing"
+
+func TestSplitAssignment(t *testing.T) {
+	var table = []struct {
+		arg   string
+		path  string
+		value string
+		ok    bool
+	}{
+		{`.a=1`, `.a`, `1`, true},
+		{`.a.b=web`, `.a.b`, `web`, true},
+		{`.a=`, `.a`, ``, true},
+		{`.a=b=c`, `.a`, `b=c`, true},
+		{`.a="x=y"`, `.a`, `"x=y"`, true},
+		{`.["a=b"]=1`, `.["a=b"]`, `1`, true},
+		{`."a=b"=1`, `."a=b"`, `1`, true},
+		{`."a\"=b"=1`, `."a\"=b"`, `1`, true},
+		{`.["\("=")"]=1`, `.["\("=")"]`, `1`, true},
+		{`.x|select(.y=="z")=1`, `.x|select(.y=="z")`, `1`, true},
+		{`.a[.b=1]=2`, `.a[.b=1]`, `2`, true},
+		{`.a==1`, ``, ``, false},
+		{`.a|=1`, ``, ``, false},
+		{`.a>=1`, ``, ``, false},
+		{`.a`, ``, ``, false},
+		{`a=1`, ``, ``, false},
+		{`file.json`, ``, ``, false},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.arg, func(t *testing.T) {
+			path, value, ok := splitAssignment(tt.arg)
+			if ok != tt.ok {
+				t.Fatalf("splitAssignment() got ok: %t, want: %t", ok, tt.ok)
+			}
+			if path != tt.path || value != tt.value {
+				t.Errorf("splitAssignment() got: %q, %q, want: %q, %q", path, value, tt.path, tt.value)
+			}
+		})
+	}
+}
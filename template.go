@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"github.com/Masterminds/sprig"
+	"github.com/spf13/cobra"
+
+	"github.com/jzelinskie/faq/jq"
+)
+
+func newTemplateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "template [flags] --input [file] [template]",
+		Short: "render a Go template using a file as its data",
+		Long: `template renders a Go template with the decoded input file as its data, so that
+"{{ .key }}" refers to the "key" field of the input document.
+
+The functions from the sprig library (https://masterminds.github.io/sprig/) are
+available within the template.`,
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.ExactArgs(1),
+		RunE:                  runTemplateCmdFunc,
+	}
+
+	cmd.Flags().String("input", "", "file to use as the data for the template")
+	cmd.Flags().String("output", "", "file to write the rendered template to (defaults to stdout)")
+
+	return cmd
+}
+
+func runTemplateCmdFunc(cmd *cobra.Command, args []string) error {
+	inOpts, err := newInputOptions(cmd)
+	if err != nil {
+		return err
+	}
+
+	inputPath, _ := cmd.Flags().GetString("input")
+	if inputPath == "" {
+		return fmt.Errorf("--input is required")
+	}
+	outputPath, _ := cmd.Flags().GetString("output")
+
+	templatePath := os.ExpandEnv(args[0])
+	templateBytes, err := ioutil.ReadFile(templatePath)
+	if err != nil {
+		return fmt.Errorf("failed to read template at %s: `%s`", templatePath, err)
+	}
+
+	tmpl, err := template.New(filepath.Base(templatePath)).
+		Funcs(sprig.TxtFuncMap()).
+		Parse(string(templateBytes))
+	if err != nil {
+		return fmt.Errorf("failed to parse template at %s: %s", templatePath, err)
+	}
+
+	inputPath = os.ExpandEnv(inputPath)
+	fileJv, _, err := decodeFile(inputPath, inOpts)
+	if err != nil {
+		return err
+	}
+
+	var data interface{}
+	if fileJv != nil {
+		if err := json.Unmarshal([]byte(fileJv.Dump(jq.JvPrintNone)), &data); err != nil {
+			return fmt.Errorf("failed to convert file at %s into template data: %s", inputPath, err)
+		}
+	}
+
+	var output bytes.Buffer
+	if err := tmpl.Execute(&output, data); err != nil {
+		return fmt.Errorf("failed to render template at %s: %s", templatePath, err)
+	}
+
+	if outputPath == "" {
+		_, err = os.Stdout.Write(output.Bytes())
+		return err
+	}
+
+	if err := ioutil.WriteFile(os.ExpandEnv(outputPath), output.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write output to %s: %s", outputPath, err)
+	}
+	return nil
+}
+
+// newOutputTemplate parses the template given with --output-template.
+func newOutputTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("output").
+		Funcs(sprig.TxtFuncMap()).
+		Option("missingkey=error").
+		Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse --output-template: %s", err)
+	}
+	return tmpl, nil
+}
+
+// renderOutputTemplate renders tmpl with a result as its data.
+//
+// Consumes jv.
+func renderOutputTemplate(tmpl *template.Template, jv *jq.Jv) ([]byte, error) {
+	data := jv.ToGoVal()
+	jv.Free()
+
+	var output bytes.Buffer
+	if err := tmpl.Execute(&output, data); err != nil {
+		return nil, err
+	}
+	return output.Bytes(), nil
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jzelinskie/faq/jq"
+)
+
+// variable is a jq variable given with --arg or --argjson.
+type variable struct {
+	name string
+
+	// value is the value of the variable as JSON text, which is also a jq
+	// literal.
+	value string
+}
+
+// newVariables parses the variables given with --arg and --argjson.
+func newVariables(cmd *cobra.Command) ([]variable, error) {
+	var vars []variable
+
+	args, _ := cmd.Flags().GetStringArray("arg")
+	for _, arg := range args {
+		name, value, err := splitVariable(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --arg %s: %s", arg, err)
+		}
+		vars = append(vars, variable{name: name, value: jq.JvFromString(value).Dump(jq.JvPrintNone)})
+	}
+
+	argsJSON, _ := cmd.Flags().GetStringArray("argjson")
+	for _, arg := range argsJSON {
+		name, value, err := splitVariable(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --argjson %s: %s", arg, err)
+		}
+		valueJv, err := jq.JvFromJSONString(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --argjson %s: %s", arg, err)
+		}
+		vars = append(vars, variable{name: name, value: valueJv.Dump(jq.JvPrintNone)})
+	}
+
+	return vars, nil
+}
+
+// splitVariable splits a NAME=VALUE argument, allowing the name to be given
+// with its "$".
+func splitVariable(arg string) (string, string, error) {
+	i := strings.Index(arg, "=")
+	if i < 0 {
+		return "", "", errors.New("expected NAME=VALUE")
+	}
+
+	name := strings.TrimPrefix(arg[:i], "$")
+	if !identRegexp.MatchString(name) {
+		return "", "", fmt.Errorf("%q is not a valid variable name", name)
+	}
+	return name, arg[i+1:], nil
+}
+
+// bindVariables returns program with each of vars bound as a variable.
+//
+// The args of jq_compile_args are over-freed by libjq 1.6, so the values are
+// bound by the program itself instead. The bindings are inserted after any
+// module, import and include directives, which jq only accepts at the start
+// of a program.
+func bindVariables(program string, vars []variable) string {
+	if len(vars) == 0 {
+		return program
+	}
+
+	i := directivesEnd(program)
+	var b strings.Builder
+	b.WriteString(program[:i])
+	for _, v := range vars {
+		fmt.Fprintf(&b, "%s as $%s | ", v.value, v.name)
+	}
+	b.WriteString(program[i:])
+	return b.String()
+}
+
+// directivesEnd returns the offset in program just past its leading module,
+// import and include directives, or 0 if it has none.
+func directivesEnd(program string) int {
+	end := 0
+	for {
+		i := skipSpaceAndComments(program, end)
+		if !startsDirective(program[i:]) {
+			return end
+		}
+		next := directiveEnd(program, i)
+		if next < 0 {
+			// The directive is unterminated, which jq reports when compiling.
+			return end
+		}
+		end = next
+	}
+}
+
+// directiveEnd returns the offset just past the ";" that ends the directive
+// starting at i, skipping over its strings and metadata object, or -1 if the
+// directive is unterminated.
+func directiveEnd(program string, i int) int {
+	depth, inString := 0, false
+	for ; i < len(program); i++ {
+		switch c := program[i]; {
+		case inString && c == '\\':
+			i++
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{' || c == '[' || c == '(':
+			depth++
+		case c == '}' || c == ']' || c == ')':
+			depth--
+		case c == ';' && depth == 0:
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// skipSpaceAndComments returns the offset of the first character of program at
+// or after i that isn't whitespace or part of a comment.
+func skipSpaceAndComments(program string, i int) int {
+	for i < len(program) {
+		switch program[i] {
+		case ' ', '\t', '\n', '\r':
+			i++
+		case '#':
+			for i < len(program) && program[i] != '\n' {
+				i++
+			}
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// startsDirective reports whether s starts with the keyword of a module,
+// import or include directive.
+func startsDirective(s string) bool {
+	for _, keyword := range []string{"module", "import", "include"} {
+		if strings.HasPrefix(s, keyword) {
+			rest := s[len(keyword):]
+			return rest == "" || !identRegexp.MatchString("_"+rest[:1])
+		}
+	}
+	return false
+}
+
+// printVariables prints the variables that program references without
+// running it, returning an error if any of them isn't one of vars.
+func printVariables(program string, vars []variable) error {
+	names, err := jq.FreeVariables(program)
+	if err != nil {
+		return fmt.Errorf("failed to compile jq program: %s", err)
+	}
+
+	provided := make(map[string]bool, len(vars))
+	for _, v := range vars {
+		provided[v.name] = true
+	}
+
+	var missing []string
+	for _, name := range names {
+		fmt.Println("$" + name)
+		if !provided[name] {
+			missing = append(missing, "$"+name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("variables not provided with --arg or --argjson: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
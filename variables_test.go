@@ -0,0 +1,34 @@
+package main
+
+import "testing"
+
+func TestBindVariables(t *testing.T) {
+	vars := []variable{{name: "a", value: `"x"`}, {name: "b", value: `1`}}
+	var table = []struct {
+		name    string
+		program string
+		bound   string
+	}{
+		{"plain", `.`, `"x" as $a | 1 as $b | .`},
+		{"defs", `def f: $a; f`, `"x" as $a | 1 as $b | def f: $a; f`},
+		{"import", `import "lib" as lib; lib::f`, `import "lib" as lib;"x" as $a | 1 as $b |  lib::f`},
+		{"include", "include \"lib\";\n.", "include \"lib\";\"x\" as $a | 1 as $b | \n."},
+		{"module", `module {name: "m;"}; import "a;b" as $data; .`, `module {name: "m;"}; import "a;b" as $data;"x" as $a | 1 as $b |  .`},
+		{"comment", "# import \"lib\";\n.", "\"x\" as $a | 1 as $b | # import \"lib\";\n."},
+		{"escaped quote", `import "a\";" as lib; .`, `import "a\";" as lib;"x" as $a | 1 as $b |  .`},
+		{"unterminated", `import "lib" as lib`, `"x" as $a | 1 as $b | import "lib" as lib`},
+		{"keyword prefix", `imports`, `"x" as $a | 1 as $b | imports`},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			if bound := bindVariables(tt.program, vars); bound != tt.bound {
+				t.Errorf("bindVariables() got: %q, want: %q", bound, tt.bound)
+			}
+		})
+	}
+
+	if bound := bindVariables(`.`, nil); bound != `.` {
+		t.Errorf("bindVariables() without variables got: %q, want: %q", bound, `.`)
+	}
+}